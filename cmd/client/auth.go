@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const authTimeout = 5 * time.Second
+
+type session struct {
+	token      string
+	signer     pubsub.Signer
+	decryptKey *ecdh.PrivateKey
+	keys       *keyRing
+}
+
+type keyRing struct {
+	keys       map[string]ed25519.PublicKey
+	encryption map[string]*ecdh.PublicKey
+	mu         *sync.RWMutex
+}
+
+func newKeyRing() *keyRing {
+	return &keyRing{
+		keys:       map[string]ed25519.PublicKey{},
+		encryption: map[string]*ecdh.PublicKey{},
+		mu:         &sync.RWMutex{},
+	}
+}
+
+func (kr *keyRing) set(username string, key []byte) {
+	if len(key) != ed25519.PublicKeySize {
+		return
+	}
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	kr.keys[username] = ed25519.PublicKey(key)
+}
+
+func (kr *keyRing) PublicKey(username string) (ed25519.PublicKey, bool) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	key, ok := kr.keys[username]
+	return key, ok
+}
+
+func (kr *keyRing) setEncryption(username string, key []byte) {
+	public, err := ecdh.X25519().NewPublicKey(key)
+	if err != nil {
+		return
+	}
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	kr.encryption[username] = public
+}
+
+func (kr *keyRing) EncryptionKey(username string) (*ecdh.PublicKey, bool) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	key, ok := kr.encryption[username]
+	return key, ok
+}
+
+func (s *session) toServer() pubsub.PublishOption {
+	key, _ := s.keys.EncryptionKey(routing.ServerSigner)
+	return pubsub.WithEncryption(key)
+}
+
+func fromServer[T any](_ string, _ T) string {
+	return routing.ServerSigner
+}
+
+func joinServer(conn *amqp.Connection, ch *amqp.Channel, username string, identity ed25519.PrivateKey, serverKey ed25519.PublicKey) (*session, error) {
+	replyTo, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+
+	signingKey := identity
+	if signingKey == nil {
+		_, signingKey, err = ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			err := fmt.Errorf("could not generate session key: %w", err)
+			return nil, err
+		}
+	}
+
+	decryptKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		err := fmt.Errorf("could not generate encryption key: %w", err)
+		return nil, err
+	}
+
+	keys := newKeyRing()
+	challenges := make(chan routing.AuthChallenge, 1)
+	exchange := routing.ExchangePerilDirect
+	queueName := routing.AuthChallengeKey + "." + replyTo
+	queueKey := routing.AuthChallengeKey + "." + replyTo
+	queueType := pubsub.QueueTypeTransient
+	if err := pubsub.SubscribeJSON(conn, exchange, queueName, queueKey, queueType, handlerAuthReply(challenges)); err != nil {
+		err := fmt.Errorf("failed to declare and bind queue: %w", err)
+		return nil, err
+	}
+
+	responses := make(chan routing.AuthResponse, 1)
+	queueName = routing.AuthKey + "." + replyTo
+	queueKey = routing.AuthKey + "." + replyTo
+	if err := pubsub.SubscribeJSON(conn, exchange, queueName, queueKey, queueType, handlerAuthReply(responses), pubsub.WithVerifier(keys, fromServer[routing.AuthResponse]), pubsub.WithDecryption[routing.AuthResponse](decryptKey)); err != nil {
+		err := fmt.Errorf("failed to declare and bind queue: %w", err)
+		return nil, err
+	}
+
+	password := ""
+	for {
+		drain(challenges)
+		drain(responses)
+		if err := pubsub.PublishJSON(ch, exchange, routing.AuthChallengeKey, routing.AuthHello{ReplyTo: replyTo}); err != nil {
+			err := fmt.Errorf("failed to publish join challenge request: %w", err)
+			return nil, err
+		}
+		challenge, err := awaitReply(challenges)
+		if err != nil {
+			return nil, err
+		}
+		if challenge.Error != "" {
+			return nil, errors.New(challenge.Error)
+		}
+		if serverKey == nil {
+			serverKey = challenge.ServerKey
+		}
+		if len(serverKey) != ed25519.PublicKeySize || !bytes.Equal(serverKey, challenge.ServerKey) {
+			return nil, errors.New("the server key does not match the pinned key, refusing to join")
+		}
+		if !ed25519.Verify(serverKey, challenge.SignedContent(), challenge.Signature) {
+			return nil, errors.New("the join challenge has an invalid server signature")
+		}
+		serverEncryption, err := ecdh.X25519().NewPublicKey(challenge.EncryptionKey)
+		if err != nil {
+			err := fmt.Errorf("server sent an invalid encryption key: %w", err)
+			return nil, err
+		}
+		keys.set(routing.ServerSigner, serverKey)
+		keys.setEncryption(routing.ServerSigner, challenge.EncryptionKey)
+
+		req := routing.AuthRequest{
+			Username:      username,
+			ReplyTo:       replyTo,
+			Nonce:         challenge.Nonce,
+			Password:      password,
+			SigningKey:    signingKey.Public().(ed25519.PublicKey),
+			EncryptionKey: decryptKey.PublicKey().Bytes(),
+			SentAt:        time.Now(),
+		}
+		if identity != nil {
+			req.Signature = ed25519.Sign(identity, req.SignedContent())
+		}
+
+		if err := pubsub.PublishJSON(ch, exchange, routing.AuthKey, req, pubsub.WithEncryption(serverEncryption)); err != nil {
+			err := fmt.Errorf("failed to publish join request: %w", err)
+			return nil, err
+		}
+		resp, err := awaitReply(responses)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.Error == routing.AuthPasswordRequired && password == "" {
+			fmt.Printf("%s is a registered user, please enter your password:\n", username)
+			password = strings.Join(gamelogic.GetInput(), " ")
+			if password == "" {
+				return nil, errors.New("no password entered")
+			}
+			continue
+		}
+		if resp.Error != "" {
+			return nil, errors.New(resp.Error)
+		}
+
+		keys.set(username, req.SigningKey)
+		keys.setEncryption(username, req.EncryptionKey)
+		return &session{
+			token:      resp.Token,
+			signer:     pubsub.Signer{Username: username, Key: signingKey},
+			decryptKey: decryptKey,
+			keys:       keys,
+		}, nil
+	}
+}
+
+func drain[T any](replies <-chan T) {
+	select {
+	case <-replies:
+	default:
+	}
+}
+
+func awaitReply[T any](replies <-chan T) (T, error) {
+	select {
+	case reply := <-replies:
+		return reply, nil
+	case <-time.After(authTimeout):
+		var zero T
+		return zero, errors.New("the server did not answer, is it running?")
+	}
+}
+
+func handlerAuthReply[T any](replies chan<- T) func(T) pubsub.AckType {
+	return func(reply T) pubsub.AckType {
+		select {
+		case replies <- reply:
+		default:
+		}
+		return pubsub.Ack
+	}
+}
+
+func loadServerKey(path string) (ed25519.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		err := fmt.Errorf("could not read server key file: %w", err)
+		return nil, err
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("server key file must contain a hex encoded ed25519 public key")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+func pinServerKey(path string, key ed25519.PublicKey) error {
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(key)+"\n"), 0644); err != nil {
+		err := fmt.Errorf("could not write server key file: %w", err)
+		return err
+	}
+	return nil
+}
+
+func loadSigningKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		err := fmt.Errorf("could not read key file: %w", err)
+		return nil, err
+	}
+	seed, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, errors.New("key file must contain a hex encoded ed25519 seed")
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+func generateSigningKey(path string) (ed25519.PublicKey, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		err := fmt.Errorf("could not generate key: %w", err)
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(private.Seed())+"\n"), 0600); err != nil {
+		err := fmt.Errorf("could not write key file: %w", err)
+		return nil, err
+	}
+	return public, nil
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		err := fmt.Errorf("could not generate random bytes: %w", err)
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
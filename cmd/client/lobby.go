@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const lobbyTimeout = 5 * time.Second
+
+var errQuit = errors.New("quit")
+
+func enterLobby(conn *amqp.Connection, ch *amqp.Channel, username string, sess *session) (string, error) {
+	responses := make(chan routing.LobbyResponse, 1)
+
+	exchange := routing.ExchangePerilDirect
+	queueName := routing.LobbyKey + "." + username
+	key := routing.LobbyKey + "." + username
+	queueType := pubsub.QueueTypeTransient
+	if err := pubsub.SubscribeJSON(conn, exchange, queueName, key, queueType, handlerLobby(responses), pubsub.WithVerifier(sess.keys, fromServer[routing.LobbyResponse])); err != nil {
+		err := fmt.Errorf("failed to declare and bind queue: %w", err)
+		return "", err
+	}
+
+	for {
+		words := gamelogic.GetInput()
+		if len(words) == 0 {
+			continue
+		}
+
+		req := routing.LobbyRequest{Username: username, Token: sess.token}
+		switch words[0] {
+		case "games":
+			req.Action = routing.LobbyList
+		case "match":
+			req.Action = routing.LobbyMatch
+		case "create", "join":
+			if len(words) < 2 {
+				slog.Error("Usage: " + words[0] + " <gameID>")
+				continue
+			}
+			req.Action = routing.LobbyAction(words[0])
+			req.GameID = words[1]
+		case "help":
+			gamelogic.PrintLobbyHelp()
+			continue
+		case "quit":
+			return "", errQuit
+		default:
+			slog.Error("Unrecognized command")
+			gamelogic.PrintLobbyHelp()
+			continue
+		}
+
+		resp, err := requestLobby(ch, sess, responses, req)
+		if err != nil {
+			slog.Error("Lobby request failed", "error", err)
+			continue
+		}
+
+		switch req.Action {
+		case routing.LobbyList:
+			if len(resp.Games) == 0 {
+				fmt.Println("No games are running.")
+			}
+			for _, info := range resp.Games {
+				fmt.Printf("* %s (%s, turn-based: %v, ended: %v): %s\n", info.ID, info.Scenario, info.TurnBased, info.Ended, strings.Join(info.Players, ", "))
+			}
+		case routing.LobbyMatch:
+			fmt.Printf("Matched into game %s!\n", resp.GameID)
+			gamelogic.PrintClientHelp()
+			return resp.GameID, nil
+		default:
+			fmt.Printf("Joined game %s!\n", resp.GameID)
+			gamelogic.PrintClientHelp()
+			return resp.GameID, nil
+		}
+	}
+}
+
+func requestLobby(ch *amqp.Channel, sess *session, responses <-chan routing.LobbyResponse, req routing.LobbyRequest) (routing.LobbyResponse, error) {
+	select {
+	case <-responses:
+	default:
+	}
+
+	exchange := routing.ExchangePerilDirect
+	key := routing.LobbyKey
+	if err := pubsub.PublishJSON(ch, exchange, key, req, sess.toServer(), pubsub.WithSigner(sess.signer)); err != nil {
+		err := fmt.Errorf("failed to publish lobby request: %w", err)
+		return routing.LobbyResponse{}, err
+	}
+
+	select {
+	case resp := <-responses:
+		if resp.Error != "" {
+			return routing.LobbyResponse{}, errors.New(resp.Error)
+		}
+		return resp, nil
+	case <-time.After(lobbyTimeout):
+		return routing.LobbyResponse{}, errors.New("the server did not answer, is it running?")
+	}
+}
+
+func handlerLobby(responses chan<- routing.LobbyResponse) func(routing.LobbyResponse) pubsub.AckType {
+	return func(resp routing.LobbyResponse) pubsub.AckType {
+		select {
+		case responses <- resp:
+		default:
+			slog.Info("Dropped a stale lobby response", "action", resp.Action)
+		}
+		return pubsub.Ack
+	}
+}
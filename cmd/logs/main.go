@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/logsink"
+)
+
+const followInterval = 500 * time.Millisecond
+
+func main() {
+	logPath := flag.String("log", "game.jsonl", "path to the structured game log")
+	username := flag.String("user", "", "only show logs from this username")
+	gameID := flag.String("game", "", "only show logs from this game")
+	contains := flag.String("grep", "", "only show logs whose message contains this text")
+	since := flag.String("since", "", "only show logs after this time (RFC3339) or this long ago (e.g. 1h)")
+	until := flag.String("until", "", "only show logs before this time (RFC3339) or this long ago (e.g. 10m)")
+	tail := flag.Int("tail", 0, "only show the last N matching logs")
+	follow := flag.Bool("follow", false, "keep printing new logs as they are written")
+	format := flag.String("format", "text", "output format, text or json")
+	flag.Parse()
+
+	filter := logsink.Filter{
+		Username: *username,
+		GameID:   *gameID,
+		Contains: *contains,
+	}
+	var err error
+	if filter.Since, err = parseTime(*since); err != nil {
+		err := fmt.Errorf("Error: invalid -since: %w", err)
+		log.Fatal(err)
+	}
+	if filter.Until, err = parseTime(*until); err != nil {
+		err := fmt.Errorf("Error: invalid -until: %w", err)
+		log.Fatal(err)
+	}
+
+	var print func(logsink.Record) error
+	switch *format {
+	case "text":
+		print = printText
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		print = func(r logsink.Record) error {
+			return encoder.Encode(r)
+		}
+	default:
+		log.Fatalf("Error: unknown format %q, use text or json", *format)
+	}
+
+	matches := []logsink.Record{}
+	if err := logsink.Read(*logPath, func(r logsink.Record) error {
+		if !filter.Match(r) {
+			return nil
+		}
+		if *tail <= 0 {
+			return print(r)
+		}
+		matches = append(matches, r)
+		if len(matches) > *tail {
+			matches = matches[1:]
+		}
+		return nil
+	}); err != nil {
+		err := fmt.Errorf("Error: failed to read logs: %w", err)
+		log.Fatal(err)
+	}
+	for _, r := range matches {
+		if err := print(r); err != nil {
+			err := fmt.Errorf("Error: failed to print log: %w", err)
+			log.Fatal(err)
+		}
+	}
+
+	if !*follow {
+		return
+	}
+	if err := followLog(*logPath, filter, print); err != nil {
+		err := fmt.Errorf("Error: failed to follow logs: %w", err)
+		log.Fatal(err)
+	}
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.New("use an RFC3339 time or a duration")
+	}
+	return t, nil
+}
+
+func printText(r logsink.Record) error {
+	line := fmt.Sprintf("%v %v: %v\n", r.Time.Format(time.RFC3339), r.Username, r.Message)
+	if r.GameID != "" {
+		line = fmt.Sprintf("%v [%v] %v: %v\n", r.Time.Format(time.RFC3339), r.GameID, r.Username, r.Message)
+	}
+	_, err := io.WriteString(os.Stdout, line)
+	return err
+}
+
+func followLog(path string, filter logsink.Filter, print func(logsink.Record) error) error {
+	f, err := os.Open(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		err := fmt.Errorf("could not open log file: %w", err)
+		return err
+	}
+	if f != nil {
+		if _, err := f.Seek(0, io.SeekEnd); err != nil {
+			err := fmt.Errorf("could not seek log file: %w", err)
+			return err
+		}
+	}
+
+	partial := ""
+	drain := func() error {
+		if f == nil {
+			return nil
+		}
+		reader := bufio.NewReader(f)
+		for {
+			chunk, err := reader.ReadString('\n')
+			partial += chunk
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				err := fmt.Errorf("could not read log file: %w", err)
+				return err
+			}
+			line := partial
+			partial = ""
+			if err := logsink.Scan(strings.NewReader(line), func(r logsink.Record) error {
+				if !filter.Match(r) {
+					return nil
+				}
+				return print(r)
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	for {
+		if err := drain(); err != nil {
+			return err
+		}
+		time.Sleep(followInterval)
+		if !rotated(f, path) {
+			continue
+		}
+
+		if err := drain(); err != nil {
+			return err
+		}
+		if f != nil {
+			f.Close()
+		}
+		f, partial = nil, ""
+		if next, err := os.Open(path); err == nil {
+			f = next
+		}
+	}
+}
+
+func rotated(f *os.File, path string) bool {
+	current, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	if f == nil {
+		return true
+	}
+	opened, err := f.Stat()
+	if err != nil {
+		return true
+	}
+	return !os.SameFile(opened, current)
+}
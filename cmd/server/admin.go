@@ -0,0 +1,272 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/storage"
+)
+
+const (
+	adminLogLimit    = 100
+	adminMaxLogLimit = 1000
+)
+
+type adminPlayer struct {
+	Username  string `json:"username"`
+	Online    bool   `json:"online"`
+	Units     int    `json:"units"`
+	Resources int    `json:"resources"`
+	Score     int    `json:"score"`
+}
+
+type adminModeration struct {
+	Username string `json:"username"`
+	Duration string `json:"duration,omitempty"`
+}
+
+type adminAPI struct {
+	srv   *server
+	store *storage.Store
+	token string
+}
+
+func newAdminHandler(srv *server, store *storage.Store, token string) http.Handler {
+	api := &adminAPI{srv: srv, store: store, token: token}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", handleDashboardPage)
+	mux.HandleFunc("GET /api/events", api.handleEvents)
+	mux.HandleFunc("GET /api/games", api.handleGames)
+	mux.HandleFunc("POST /api/games/{id}/pause", api.handlePause(true))
+	mux.HandleFunc("POST /api/games/{id}/resume", api.handlePause(false))
+	mux.HandleFunc("GET /api/games/{id}/players", api.handlePlayers)
+	mux.HandleFunc("GET /api/games/{id}/presence", api.handlePresence)
+	mux.HandleFunc("GET /api/logs", api.handleLogs)
+	mux.HandleFunc("GET /api/bans", api.handleBans)
+	mux.HandleFunc("POST /api/bans", api.handleBan)
+	mux.HandleFunc("DELETE /api/bans/{username}", api.handleUnban)
+	mux.HandleFunc("POST /api/kicks", api.handleKick)
+	mux.HandleFunc("POST /api/mutes", api.handleMute)
+	return api.authorize(mux)
+}
+
+func serveAdmin(addr string, handler http.Handler) {
+	s := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	slog.Info("Admin API listening", "addr", addr)
+	if err := s.ListenAndServe(); err != nil {
+		slog.Error("Admin API stopped", "error", err)
+	}
+}
+
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || host == "" {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (api *adminAPI) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if api.token != "" && !(r.Method == http.MethodGet && r.URL.Path == "/") {
+			token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if token == "" && r.Method == http.MethodGet && r.URL.Path == "/api/events" {
+				token = r.URL.Query().Get("token")
+			}
+			if subtle.ConstantTimeCompare([]byte(token), []byte(api.token)) != 1 {
+				writeError(w, http.StatusUnauthorized, errors.New("a valid bearer token is required"))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (api *adminAPI) game(w http.ResponseWriter, r *http.Request) (*game, bool) {
+	id := r.PathValue("id")
+	g, ok := api.srv.getGame(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("game %s does not exist", id))
+	}
+	return g, ok
+}
+
+func (api *adminAPI) handleGames(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, api.srv.listGames())
+}
+
+func (api *adminAPI) handlePause(paused bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		g, ok := api.game(w, r)
+		if !ok {
+			return
+		}
+		if err := g.setPaused(paused); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		slog.Info("Pause changed through the admin API", "game", g.id, "paused", paused)
+		writeJSON(w, http.StatusOK, map[string]bool{"paused": paused})
+	}
+}
+
+func (api *adminAPI) handlePlayers(w http.ResponseWriter, r *http.Request) {
+	g, ok := api.game(w, r)
+	if !ok {
+		return
+	}
+	snapshot := g.world.snapshot()
+	players := []adminPlayer{}
+	for _, username := range g.info().Players {
+		p := snapshot[username]
+		players = append(players, adminPlayer{
+			Username:  username,
+			Online:    g.presence.isOnline(username),
+			Units:     len(p.Units),
+			Resources: p.Resources,
+			Score:     g.scenario.Score(p),
+		})
+	}
+	writeJSON(w, http.StatusOK, players)
+}
+
+func (api *adminAPI) handlePresence(w http.ResponseWriter, r *http.Request) {
+	g, ok := api.game(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, g.presence.list())
+}
+
+func (api *adminAPI) handleLogs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit := adminLogLimit
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > adminMaxLogLimit {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("limit must be between 1 and %d", adminMaxLogLimit))
+			return
+		}
+		limit = n
+	}
+
+	logs, err := api.store.Logs(query.Get("user"), query.Get("game"), limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, logs)
+}
+
+func (api *adminAPI) handleBans(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, api.srv.moderator.bans.list())
+}
+
+func (api *adminAPI) handleBan(w http.ResponseWriter, r *http.Request) {
+	req, duration, ok := readModeration(w, r)
+	if !ok {
+		return
+	}
+	if err := api.srv.ban(req.Username, duration); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	slog.Info("Player banned through the admin API", "username", req.Username, "duration", duration)
+	record, _ := api.srv.moderator.bans.banned(req.Username)
+	writeJSON(w, http.StatusCreated, record)
+}
+
+func (api *adminAPI) handleUnban(w http.ResponseWriter, r *http.Request) {
+	username := r.PathValue("username")
+	lifted, err := api.srv.unban(username)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !lifted {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%s is not banned or muted", username))
+		return
+	}
+	slog.Info("Player unbanned through the admin API", "username", username)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (api *adminAPI) handleKick(w http.ResponseWriter, r *http.Request) {
+	req, _, ok := readModeration(w, r)
+	if !ok {
+		return
+	}
+	if err := api.srv.kick(req.Username, "kicked by an administrator"); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	slog.Info("Player kicked through the admin API", "username", req.Username)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (api *adminAPI) handleMute(w http.ResponseWriter, r *http.Request) {
+	req, duration, ok := readModeration(w, r)
+	if !ok {
+		return
+	}
+	if duration == 0 {
+		duration = muteDuration
+	}
+	if err := api.srv.mute(req.Username, duration); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	slog.Info("Player muted through the admin API", "username", req.Username, "duration", duration)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func readModeration(w http.ResponseWriter, r *http.Request) (adminModeration, time.Duration, bool) {
+	var req adminModeration
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return adminModeration{}, 0, false
+	}
+	if req.Username == "" {
+		writeError(w, http.StatusBadRequest, errors.New("username is required"))
+		return adminModeration{}, 0, false
+	}
+
+	var duration time.Duration
+	if req.Duration != "" {
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil || d <= 0 {
+			writeError(w, http.StatusBadRequest, errors.New("invalid duration, use e.g. 30s, 10m or 24h"))
+			return adminModeration{}, 0, false
+		}
+		duration = d
+	}
+	return req, duration, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Failed to write admin response", "error", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIsLoopback(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"127.0.0.1:8080", true},
+		{"127.0.0.2:8080", true},
+		{"[::1]:8080", true},
+		{"localhost:8080", true},
+		{":8080", false},
+		{"0.0.0.0:8080", false},
+		{"[::]:8080", false},
+		{"192.168.1.10:8080", false},
+		{"example.com:8080", false},
+		{"127.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := isLoopback(tt.addr); got != tt.want {
+			t.Errorf("isLoopback(%q) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	api := &adminAPI{token: "secret"}
+	handler := api.authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		bearer string
+		want   int
+	}{
+		{"dashboard page is public", http.MethodGet, "/", "", http.StatusOK},
+		{"bearer token", http.MethodGet, "/api/games", "secret", http.StatusOK},
+		{"missing token", http.MethodGet, "/api/games", "", http.StatusUnauthorized},
+		{"wrong token", http.MethodGet, "/api/games", "guess", http.StatusUnauthorized},
+		{"query token for events", http.MethodGet, "/api/events?token=secret", "", http.StatusOK},
+		{"query token elsewhere", http.MethodGet, "/api/games?token=secret", "", http.StatusUnauthorized},
+		{"query token for bans", http.MethodPost, "/api/bans?token=secret", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("%s %s = %d, want %d", tt.method, tt.target, rec.Code, tt.want)
+			}
+		})
+	}
+}
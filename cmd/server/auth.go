@@ -0,0 +1,373 @@
+package main
+
+import (
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	authRequestMaxAge    = 30 * time.Second
+	maxPendingChallenges = 1024
+)
+
+type userRecord struct {
+	Username     string `json:"username"`
+	PasswordHash string `json:"password_hash,omitempty"`
+	PublicKey    []byte `json:"public_key,omitempty"`
+}
+
+type userStore struct {
+	path  string
+	users map[string]userRecord
+	mu    *sync.RWMutex
+}
+
+func loadUserStore(path string) (*userStore, error) {
+	store := &userStore{
+		path:  path,
+		users: map[string]userRecord{},
+		mu:    &sync.RWMutex{},
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		err := fmt.Errorf("could not read user file: %w", err)
+		return nil, err
+	}
+
+	records := []userRecord{}
+	if err := json.Unmarshal(data, &records); err != nil {
+		err := fmt.Errorf("could not decode user file: %w", err)
+		return nil, err
+	}
+	for _, record := range records {
+		store.users[record.Username] = record
+	}
+	return store, nil
+}
+
+func (us *userStore) get(username string) (userRecord, bool) {
+	us.mu.RLock()
+	defer us.mu.RUnlock()
+	record, ok := us.users[username]
+	return record, ok
+}
+
+func (us *userStore) setPassword(username, password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		err := fmt.Errorf("could not hash password: %w", err)
+		return err
+	}
+
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	record := us.users[username]
+	record.Username = username
+	record.PasswordHash = string(hash)
+	us.users[username] = record
+	return us.save()
+}
+
+func (us *userStore) setPublicKey(username string, key ed25519.PublicKey) error {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	record := us.users[username]
+	record.Username = username
+	record.PublicKey = key
+	us.users[username] = record
+	return us.save()
+}
+
+func (us *userStore) save() error {
+	records := []userRecord{}
+	for _, record := range us.users {
+		records = append(records, record)
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		err := fmt.Errorf("could not encode user file: %w", err)
+		return err
+	}
+	if err := os.WriteFile(us.path, data, 0600); err != nil {
+		err := fmt.Errorf("could not write user file: %w", err)
+		return err
+	}
+	return nil
+}
+
+func (us *userStore) authenticate(req routing.AuthRequest) (bool, error) {
+	record, ok := us.get(req.Username)
+	if !ok {
+		return false, nil
+	}
+
+	if len(record.PublicKey) == ed25519.PublicKeySize && len(req.Signature) > 0 {
+		if !ed25519.Verify(record.PublicKey, req.SignedContent(), req.Signature) {
+			return false, errors.New("invalid signature")
+		}
+		return true, nil
+	}
+
+	if record.PasswordHash != "" {
+		if req.Password == "" {
+			return false, errors.New(routing.AuthPasswordRequired)
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(record.PasswordHash), []byte(req.Password)); err != nil {
+			return false, errors.New("invalid password")
+		}
+		return true, nil
+	}
+
+	return false, errors.New("a signing key is required for this user")
+}
+
+type session struct {
+	token         string
+	signingKey    ed25519.PublicKey
+	encryptionKey *ecdh.PublicKey
+	lastSeen      time.Time
+}
+
+type sessions struct {
+	byUser     map[string]session
+	challenges map[string]time.Time
+	mu         *sync.Mutex
+}
+
+func newSessions() *sessions {
+	return &sessions{
+		byUser:     map[string]session{},
+		challenges: map[string]time.Time{},
+		mu:         &sync.Mutex{},
+	}
+}
+
+func (s *sessions) challenge() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for nonce, issued := range s.challenges {
+		if time.Since(issued) > authRequestMaxAge {
+			delete(s.challenges, nonce)
+		}
+	}
+	if len(s.challenges) >= maxPendingChallenges {
+		return "", errors.New("too many pending join requests, try again later")
+	}
+
+	nonce, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	s.challenges[nonce] = time.Now()
+	return nonce, nil
+}
+
+func (s *sessions) redeem(nonce string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	issued, ok := s.challenges[nonce]
+	delete(s.challenges, nonce)
+	return ok && time.Since(issued) <= authRequestMaxAge
+}
+
+func (s *sessions) open(username string, signingKey ed25519.PublicKey, encryptionKey *ecdh.PublicKey, authenticated bool) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.byUser[username]; ok && time.Since(existing.lastSeen) < presenceTimeout && !authenticated {
+		return "", fmt.Errorf("username %s is already taken", username)
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	s.byUser[username] = session{
+		token:         token,
+		signingKey:    signingKey,
+		encryptionKey: encryptionKey,
+		lastSeen:      time.Now(),
+	}
+	return token, nil
+}
+
+func (s *sessions) touch(username, token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.byUser[username]
+	if !ok || existing.token != token {
+		return false
+	}
+	existing.lastSeen = time.Now()
+	s.byUser[username] = existing
+	return true
+}
+
+func (s *sessions) valid(username, token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.byUser[username]
+	return ok && existing.token == token
+}
+
+func (s *sessions) PublicKey(username string) (ed25519.PublicKey, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.byUser[username]
+	return existing.signingKey, ok
+}
+
+func (s *sessions) EncryptionKey(username string) (*ecdh.PublicKey, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.byUser[username]
+	return existing.encryptionKey, ok && existing.encryptionKey != nil
+}
+
+func (s *sessions) drop(username string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.byUser, username)
+}
+
+func (s *sessions) close(username, token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.byUser[username]; ok && existing.token == token {
+		delete(s.byUser, username)
+	}
+}
+
+func loadServerKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			err := fmt.Errorf("could not generate server key: %w", err)
+			return nil, err
+		}
+		if err := os.WriteFile(path, []byte(hex.EncodeToString(key.Seed())+"\n"), 0600); err != nil {
+			err := fmt.Errorf("could not write server key: %w", err)
+			return nil, err
+		}
+		slog.Info("Generated a new server key", "path", path)
+		return key, nil
+	}
+	if err != nil {
+		err := fmt.Errorf("could not read server key: %w", err)
+		return nil, err
+	}
+
+	seed, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, errors.New("server key file must contain a hex encoded ed25519 seed")
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		err := fmt.Errorf("could not generate token: %w", err)
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func handlerAuthChallenge(ch *amqp.Channel, sess *sessions, signer pubsub.Signer, encryptionKey *ecdh.PrivateKey) func(routing.AuthHello) pubsub.AckType {
+	return func(hello routing.AuthHello) pubsub.AckType {
+		if hello.ReplyTo == "" {
+			return pubsub.NackDiscard
+		}
+
+		challenge := routing.AuthChallenge{
+			ServerKey:     signer.Key.Public().(ed25519.PublicKey),
+			EncryptionKey: encryptionKey.PublicKey().Bytes(),
+		}
+		nonce, err := sess.challenge()
+		if err != nil {
+			challenge.Error = err.Error()
+		}
+		challenge.Nonce = nonce
+		challenge.Signature = ed25519.Sign(signer.Key, challenge.SignedContent())
+
+		exchange := routing.ExchangePerilDirect
+		key := routing.AuthChallengeKey + "." + hello.ReplyTo
+		if err := pubsub.PublishJSON(ch, exchange, key, challenge); err != nil {
+			slog.Error("Failed to publish join challenge", "error", err)
+			return pubsub.NackRequeue
+		}
+		return pubsub.Ack
+	}
+}
+
+func handlerAuth(ch *amqp.Channel, users *userStore, bans *banList, sess *sessions, signer pubsub.Signer, requireAuth bool) func(routing.AuthRequest) pubsub.AckType {
+	return func(req routing.AuthRequest) pubsub.AckType {
+		defer fmt.Print("> ")
+		resp := routing.AuthResponse{}
+
+		encryptionKey, err := ecdh.X25519().NewPublicKey(req.EncryptionKey)
+		if err != nil || req.ReplyTo == "" {
+			slog.Info("Dropped a join request without a reply address or encryption key", "username", req.Username)
+			return pubsub.NackDiscard
+		}
+
+		redeemed := sess.redeem(req.Nonce)
+		authenticated, err := users.authenticate(req)
+		ban, banned := bans.banned(req.Username)
+		switch {
+		case !redeemed:
+			resp.Error = "join challenge is unknown or expired, please try again"
+		case err != nil:
+			resp.Error = err.Error()
+		case req.Username == "":
+			resp.Error = "a username is required"
+		case req.Username == routing.ServerSigner:
+			resp.Error = fmt.Sprintf("username %s is reserved", routing.ServerSigner)
+		case len(req.SigningKey) != ed25519.PublicKeySize:
+			resp.Error = "a valid session signing key is required"
+		case time.Since(req.SentAt) > authRequestMaxAge:
+			resp.Error = "join request expired"
+		case banned:
+			resp.Error = ban.String()
+		case requireAuth && !authenticated:
+			resp.Error = "this server only accepts registered users"
+		default:
+			token, err := sess.open(req.Username, req.SigningKey, encryptionKey, authenticated)
+			if err != nil {
+				resp.Error = err.Error()
+			}
+			resp.Token = token
+		}
+
+		slog.Info("Join request", "username", req.Username, "authenticated", authenticated, "error", resp.Error)
+		exchange := routing.ExchangePerilDirect
+		key := routing.AuthKey + "." + req.ReplyTo
+		if err := pubsub.PublishJSON(ch, exchange, key, resp, pubsub.WithEncryption(encryptionKey), pubsub.WithSigner(signer)); err != nil {
+			slog.Error("Failed to publish join response", "error", err)
+			return pubsub.NackRequeue
+		}
+
+		return pubsub.Ack
+	}
+}
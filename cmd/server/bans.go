@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type banRecord struct {
+	Username string    `json:"username"`
+	Reason   string    `json:"reason,omitempty"`
+	Until    time.Time `json:"until,omitempty"`
+}
+
+type banList struct {
+	path string
+	bans map[string]banRecord
+	mu   *sync.RWMutex
+}
+
+func loadBanList(path string) (*banList, error) {
+	list := &banList{
+		path: path,
+		bans: map[string]banRecord{},
+		mu:   &sync.RWMutex{},
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return list, nil
+	}
+	if err != nil {
+		err := fmt.Errorf("could not read ban file: %w", err)
+		return nil, err
+	}
+
+	records := []banRecord{}
+	if err := json.Unmarshal(data, &records); err != nil {
+		err := fmt.Errorf("could not decode ban file: %w", err)
+		return nil, err
+	}
+	for _, record := range records {
+		list.bans[record.Username] = record
+	}
+	return list, nil
+}
+
+func (bl *banList) ban(record banRecord) error {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	bl.bans[record.Username] = record
+	return bl.save()
+}
+
+func (bl *banList) unban(username string) (bool, error) {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	if _, ok := bl.bans[username]; !ok {
+		return false, nil
+	}
+	delete(bl.bans, username)
+	return true, bl.save()
+}
+
+func (bl *banList) banned(username string) (banRecord, bool) {
+	bl.mu.RLock()
+	defer bl.mu.RUnlock()
+	record, ok := bl.bans[username]
+	if !ok || (!record.Until.IsZero() && time.Now().After(record.Until)) {
+		return banRecord{}, false
+	}
+	return record, true
+}
+
+func (bl *banList) list() []banRecord {
+	bl.mu.RLock()
+	defer bl.mu.RUnlock()
+	records := []banRecord{}
+	for _, record := range bl.bans {
+		if record.Until.IsZero() || time.Now().Before(record.Until) {
+			records = append(records, record)
+		}
+	}
+	slices.SortFunc(records, func(a, b banRecord) int {
+		return strings.Compare(a.Username, b.Username)
+	})
+	return records
+}
+
+func (bl *banList) save() error {
+	records := []banRecord{}
+	for _, record := range bl.bans {
+		records = append(records, record)
+	}
+	slices.SortFunc(records, func(a, b banRecord) int {
+		return strings.Compare(a.Username, b.Username)
+	})
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		err := fmt.Errorf("could not encode ban file: %w", err)
+		return err
+	}
+	if err := os.WriteFile(bl.path, data, 0600); err != nil {
+		err := fmt.Errorf("could not write ban file: %w", err)
+		return err
+	}
+	return nil
+}
+
+func (r banRecord) String() string {
+	if r.Until.IsZero() {
+		return fmt.Sprintf("%s is banned permanently", r.Username)
+	}
+	return fmt.Sprintf("%s is banned until %s", r.Username, r.Until.Format(time.DateTime))
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+//go:embed dashboard.html
+var dashboardPage []byte
+
+const (
+	dashboardBuffer    = 64
+	dashboardKeepAlive = 15 * time.Second
+)
+
+type dashboardEvent struct {
+	Type   string    `json:"type"`
+	GameID string    `json:"game_id"`
+	Time   time.Time `json:"time"`
+	Data   any       `json:"data"`
+}
+
+type dashboardLocation struct {
+	Name  gamelogic.Location `json:"name"`
+	Units map[string]int     `json:"units"`
+}
+
+type dashboardMove struct {
+	Username string               `json:"username"`
+	To       gamelogic.Location   `json:"to"`
+	Ranks    []gamelogic.UnitRank `json:"ranks"`
+	Turn     int                  `json:"turn,omitempty"`
+}
+
+type dashboardWar struct {
+	Location      gamelogic.Location `json:"location"`
+	Attacker      string             `json:"attacker"`
+	Defender      string             `json:"defender"`
+	Winner        string             `json:"winner"`
+	AttackerPower int                `json:"attacker_power"`
+	DefenderPower int                `json:"defender_power"`
+	Casualties    map[string]int     `json:"casualties"`
+}
+
+type dashboardLog struct {
+	Username string `json:"username"`
+	Message  string `json:"message"`
+}
+
+type dashboard struct {
+	clients map[chan dashboardEvent]string
+	mu      *sync.Mutex
+}
+
+func newDashboard() *dashboard {
+	return &dashboard{
+		clients: map[chan dashboardEvent]string{},
+		mu:      &sync.Mutex{},
+	}
+}
+
+func (d *dashboard) subscribe(gameID string) chan dashboardEvent {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	events := make(chan dashboardEvent, dashboardBuffer)
+	d.clients[events] = gameID
+	return events
+}
+
+func (d *dashboard) unsubscribe(events chan dashboardEvent) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.clients, events)
+}
+
+func (d *dashboard) publish(eventType, gameID string, data any) {
+	event := dashboardEvent{Type: eventType, GameID: gameID, Time: time.Now(), Data: data}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for events, game := range d.clients {
+		if game != gameID {
+			continue
+		}
+		select {
+		case events <- event:
+		default:
+			slog.Info("Dropped a dashboard event for a slow client", "type", eventType, "game", gameID)
+		}
+	}
+}
+
+func (d *dashboard) world(w *world) {
+	d.publish("map", w.gameID, unitMap(w))
+}
+
+func (d *dashboard) move(gameID string, move gamelogic.ArmyMove) {
+	ranks := []gamelogic.UnitRank{}
+	for _, unit := range move.Units {
+		ranks = append(ranks, unit.Rank)
+	}
+	d.publish("move", gameID, dashboardMove{
+		Username: move.Player.Username,
+		To:       move.ToLocation,
+		Ranks:    ranks,
+		Turn:     move.Turn,
+	})
+}
+
+func (d *dashboard) war(gameID string, br gamelogic.BattleResult) {
+	casualties := map[string]int{}
+	for username, ids := range br.Casualties {
+		casualties[username] = len(ids)
+	}
+	d.publish("war", gameID, dashboardWar{
+		Location:      br.Location,
+		Attacker:      br.Attacker,
+		Defender:      br.Defender,
+		Winner:        br.Winner,
+		AttackerPower: br.AttackerPower,
+		DefenderPower: br.DefenderPower,
+		Casualties:    casualties,
+	})
+}
+
+func (d *dashboard) gameOver(over routing.GameOver) {
+	d.publish("game_over", over.GameID, over)
+}
+
+func (d *dashboard) Write(logs ...routing.GameLog) error {
+	for _, gl := range logs {
+		d.publish("log", gl.GameID, dashboardLog{Username: gl.Username, Message: gl.Message})
+	}
+	return nil
+}
+
+func (d *dashboard) Sync() error {
+	return nil
+}
+
+func (d *dashboard) Close() error {
+	return nil
+}
+
+func unitMap(w *world) []dashboardLocation {
+	players := w.snapshot()
+	locations := []dashboardLocation{}
+	for _, loc := range w.scenario.Locations {
+		units := map[string]int{}
+		for username, p := range players {
+			for _, unit := range p.Units {
+				if unit.Location == loc.Name {
+					units[username]++
+				}
+			}
+		}
+		locations = append(locations, dashboardLocation{Name: loc.Name, Units: units})
+	}
+	return locations
+}
+
+func handleDashboardPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(dashboardPage)
+}
+
+func (api *adminAPI) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming is not supported"))
+		return
+	}
+
+	gameID := r.URL.Query().Get("game")
+	if gameID == "" {
+		gameID = routing.DefaultGameID
+	}
+	g, ok := api.srv.getGame(gameID)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("game %s does not exist", gameID))
+		return
+	}
+
+	events := api.srv.dashboard.subscribe(gameID)
+	defer api.srv.dashboard.unsubscribe(events)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	initial := []dashboardEvent{{Type: "map", GameID: gameID, Time: time.Now(), Data: unitMap(g.world)}}
+	if over, ended := g.world.outcome(); ended {
+		initial = append(initial, dashboardEvent{Type: "game_over", GameID: gameID, Time: over.EndedAt, Data: over})
+	}
+	for _, event := range initial {
+		if err := writeEvent(w, event); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(dashboardKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case event := <-events:
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event dashboardEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		err := fmt.Errorf("could not encode dashboard event: %w", err)
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+		return err
+	}
+	return nil
+}
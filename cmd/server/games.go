@@ -0,0 +1,434 @@
+package main
+
+import (
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/storage"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+var validGameID = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+type game struct {
+	id       string
+	scenario gamelogic.Scenario
+	world    *world
+	presence *presence
+	clock    *turnClock
+	wars     *warLedger
+	store    *storage.Store
+	ch       *amqp.Channel
+	started  time.Time
+}
+
+type server struct {
+	conn          *amqp.Connection
+	ch            *amqp.Channel
+	scenario      gamelogic.Scenario
+	turnLength    time.Duration
+	signer        pubsub.Signer
+	encryptionKey *ecdh.PrivateKey
+	sessions      *sessions
+	moderator     *moderator
+	dashboard     *dashboard
+	store         *storage.Store
+	games         map[string]*game
+	mu            *sync.RWMutex
+}
+
+func newServer(conn *amqp.Connection, ch *amqp.Channel, scenario gamelogic.Scenario, turnLength time.Duration, signingKey ed25519.PrivateKey, encryptionKey *ecdh.PrivateKey, bans *banList, store *storage.Store, dash *dashboard) *server {
+	signer := pubsub.Signer{Username: routing.ServerSigner, Key: signingKey}
+	return &server{
+		conn:          conn,
+		ch:            ch,
+		scenario:      scenario,
+		turnLength:    turnLength,
+		signer:        signer,
+		encryptionKey: encryptionKey,
+		sessions:      newSessions(),
+		moderator:     newModerator(ch, signer, bans),
+		dashboard:     dash,
+		store:         store,
+		games:         map[string]*game{},
+		mu:            &sync.RWMutex{},
+	}
+}
+
+func (s *server) createGame(id string) (*game, error) {
+	if !validGameID.MatchString(id) {
+		return nil, fmt.Errorf("invalid game ID %q, use up to 32 lowercase letters, digits, dashes or underscores", id)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.games[id]; ok {
+		return nil, fmt.Errorf("game %s already exists", id)
+	}
+
+	g := &game{
+		id:       id,
+		scenario: s.scenario,
+		world:    newWorld(id, s.scenario, s.signer, s.sessions, s.dashboard),
+		presence: newPresence(id, s.ch, s.signer),
+		wars:     newWarLedger(id),
+		store:    s.store,
+		ch:       s.ch,
+		started:  time.Now(),
+	}
+	if s.turnLength > 0 {
+		g.clock = newTurnClock(s.ch, g.world, s.turnLength)
+	}
+
+	if err := g.subscribe(s.conn, s.sessions, s.moderator, s.encryptionKey); err != nil {
+		err := fmt.Errorf("failed to subscribe game %s: %w", id, err)
+		return nil, err
+	}
+	go g.presence.run()
+	go g.watchVictory()
+	if g.clock != nil {
+		go g.clock.run()
+	} else {
+		go g.collectIncome()
+	}
+	if err := g.publishScenario(); err != nil {
+		err := fmt.Errorf("failed to publish scenario: %w", err)
+		return nil, err
+	}
+
+	if err := s.store.CreateGame(g.info(), time.Now()); err != nil {
+		slog.Error("Failed to save game", "game", id, "error", err)
+	}
+
+	s.games[id] = g
+	slog.Info("Game created", "game", id, "turnBased", g.clock != nil)
+	return g, nil
+}
+
+func (s *server) getGame(id string) (*game, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	g, ok := s.games[id]
+	return g, ok
+}
+
+func (s *server) listGames() []routing.GameInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	games := []routing.GameInfo{}
+	for _, g := range s.games {
+		games = append(games, g.info())
+	}
+	slices.SortFunc(games, func(a, b routing.GameInfo) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return games
+}
+
+func (g *game) subscribe(conn *amqp.Connection, sess *sessions, mod *moderator, encryptionKey *ecdh.PrivateKey) error {
+	queueType := pubsub.QueueTypeDurable
+
+	exchange := routing.ExchangePerilTopic
+	queueName := routing.GameKey(g.id, routing.ArmyMovesPrefix)
+	key := routing.GameKey(g.id, routing.ArmyMovesPrefix, "*")
+	if err := pubsub.SubscribeJSON(conn, exchange, queueName, key, queueType, handlerArmyMoves(g.ch, g.world), pubsub.WithVerifier(sess, pubsub.KeySuffix, moveSender), pubsub.WithDecryption[gamelogic.ArmyMove](encryptionKey), pubsub.WithAdmission(mod.admitMove)); err != nil {
+		err := fmt.Errorf("failed to subscribe to army_moves queue: %w", err)
+		return err
+	}
+
+	queueName = routing.GameKey(g.id, routing.PlayerStatePrefix)
+	key = routing.GameKey(g.id, routing.PlayerStatePrefix, "*")
+	if err := pubsub.SubscribeJSON(conn, exchange, queueName, key, queueType, handlerPlayerState(g.world), pubsub.WithVerifier(sess, pubsub.KeySuffix, playerSender), pubsub.WithDecryption[gamelogic.Player](encryptionKey)); err != nil {
+		err := fmt.Errorf("failed to subscribe to player_state queue: %w", err)
+		return err
+	}
+
+	queueName = routing.GameKey(g.id, routing.PresencePrefix)
+	key = routing.GameKey(g.id, routing.PresencePrefix, "*")
+	if err := pubsub.SubscribeJSON(conn, exchange, queueName, key, queueType, handlerPresence(g.presence, sess), pubsub.WithVerifier(sess, pubsub.KeySuffix, presenceSender), pubsub.WithDecryption[routing.Presence](encryptionKey)); err != nil {
+		err := fmt.Errorf("failed to subscribe to presence queue: %w", err)
+		return err
+	}
+
+	if g.clock != nil {
+		queueName = routing.GameKey(g.id, routing.OrdersPrefix)
+		key = routing.GameKey(g.id, routing.OrdersPrefix, "*")
+		if err := pubsub.SubscribeJSON(conn, exchange, queueName, key, queueType, handlerOrders(g.clock), pubsub.WithVerifier(sess, pubsub.KeySuffix, moveSender), pubsub.WithDecryption[gamelogic.ArmyMove](encryptionKey), pubsub.WithAdmission(mod.admitMove)); err != nil {
+			err := fmt.Errorf("failed to subscribe to orders queue: %w", err)
+			return err
+		}
+	}
+
+	queueName = routing.GameKey(g.id, routing.WarDeclarationsPrefix)
+	key = routing.GameKey(g.id, routing.WarDeclarationsPrefix, "*")
+	if err := pubsub.SubscribeJSON(conn, exchange, queueName, key, queueType, handlerWarDeclarations(g), pubsub.WithVerifier(sess, pubsub.KeySuffix, warDeclarer), pubsub.WithDecryption[gamelogic.RecognitionOfWar](encryptionKey)); err != nil {
+		err := fmt.Errorf("failed to subscribe to war_declarations queue: %w", err)
+		return err
+	}
+
+	queueName = routing.GameKey(g.id, routing.WarResultsPrefix)
+	key = routing.GameKey(g.id, routing.WarResultsPrefix, "*")
+	if err := pubsub.SubscribeJSON(conn, exchange, queueName, key, queueType, handlerWarResults(g), pubsub.WithVerifier(sess, pubsub.KeySuffix, battleSender)); err != nil {
+		err := fmt.Errorf("failed to subscribe to war_results queue: %w", err)
+		return err
+	}
+
+	queueName = routing.GameKey(g.id, routing.BattleReportsPrefix)
+	key = routing.GameKey(g.id, routing.BattleReportsPrefix, "*")
+	if err := pubsub.SubscribeJSON(conn, exchange, queueName, key, queueType, handlerBattleReports(g), pubsub.WithVerifier(sess, pubsub.KeySuffix, battleConfirmer), pubsub.WithDecryption[gamelogic.BattleResult](encryptionKey)); err != nil {
+		err := fmt.Errorf("failed to subscribe to battle_reports queue: %w", err)
+		return err
+	}
+
+	exchange = routing.ExchangePerilDirect
+	queueName = routing.GameKey(g.id, routing.ScenarioRequestKey)
+	key = routing.GameKey(g.id, routing.ScenarioRequestKey)
+	if err := pubsub.SubscribeJSON(conn, exchange, queueName, key, queueType, handlerScenarioRequest(g)); err != nil {
+		err := fmt.Errorf("failed to subscribe to scenario requests: %w", err)
+		return err
+	}
+
+	return nil
+}
+
+func (g *game) info() routing.GameInfo {
+	players := []string{}
+	for username := range g.world.snapshot() {
+		players = append(players, username)
+	}
+	slices.Sort(players)
+	_, ended := g.world.outcome()
+	return routing.GameInfo{
+		ID:        g.id,
+		Players:   players,
+		TurnBased: g.clock != nil,
+		Scenario:  g.scenario.Name,
+		Ended:     ended,
+	}
+}
+
+func (g *game) setPaused(paused bool) error {
+	exchange := routing.ExchangePerilDirect
+	key := routing.GameKey(g.id, routing.PauseKey)
+	if err := pubsub.PublishJSON(g.ch, exchange, key, routing.PlayingState{IsPaused: paused}, pubsub.WithSigner(g.world.signer)); err != nil {
+		err := fmt.Errorf("failed to publish JSON: %w", err)
+		return err
+	}
+
+	g.world.setPaused(paused)
+	if g.clock != nil {
+		if paused {
+			g.clock.pause()
+		} else {
+			g.clock.resume()
+		}
+	}
+	return nil
+}
+
+func (g *game) collectIncome() {
+	ticker := time.NewTicker(g.scenario.IncomeInterval())
+	defer ticker.Stop()
+	for range ticker.C {
+		if _, over := g.world.outcome(); over {
+			return
+		}
+		g.world.collectIncome()
+	}
+}
+
+func (g *game) publishScenario() error {
+	exchange := routing.ExchangePerilDirect
+	key := routing.GameKey(g.id, routing.ScenarioKey)
+	if err := pubsub.PublishJSON(g.ch, exchange, key, g.scenario, pubsub.WithSigner(g.world.signer)); err != nil {
+		err := fmt.Errorf("failed to publish JSON: %w", err)
+		return err
+	}
+
+	return nil
+}
+
+func handlerScenarioRequest(g *game) func(routing.ScenarioRequest) pubsub.AckType {
+	return func(sr routing.ScenarioRequest) pubsub.AckType {
+		defer fmt.Print("> ")
+		slog.Info("Scenario requested", "game", g.id, "username", sr.Username)
+		g.world.register(sr.Username)
+		if err := g.store.JoinGame(g.id, sr.Username, time.Now()); err != nil {
+			slog.Error("Failed to save player", "game", g.id, "username", sr.Username, "error", err)
+		}
+		if err := g.publishScenario(); err != nil {
+			slog.Error("Failed to publish scenario", "error", err)
+			return pubsub.NackRequeue
+		}
+		if over, ok := g.world.outcome(); ok {
+			if err := g.publishGameOver(over); err != nil {
+				slog.Error("Failed to publish game over", "error", err)
+				return pubsub.NackRequeue
+			}
+		}
+		if g.clock != nil {
+			if err := g.clock.announce(); err != nil {
+				slog.Error("Failed to announce turn", "error", err)
+				return pubsub.NackRequeue
+			}
+		}
+
+		return pubsub.Ack
+	}
+}
+
+func warDeclarer(_ string, rw gamelogic.RecognitionOfWar) string {
+	return rw.Defender.Username
+}
+
+func battleSender(_ string, br gamelogic.BattleResult) string {
+	return br.Attacker
+}
+
+func battleConfirmer(_ string, br gamelogic.BattleResult) string {
+	return br.Defender
+}
+
+func handlerWarDeclarations(g *game) func(gamelogic.RecognitionOfWar) pubsub.AckType {
+	return func(rw gamelogic.RecognitionOfWar) pubsub.AckType {
+		seed, err := g.wars.declare(rw)
+		if err != nil {
+			slog.Error("Failed to declare war", "game", g.id, "error", err)
+			return pubsub.NackRequeue
+		}
+		rw.Seed = seed
+
+		recipient, ok := g.world.keys.EncryptionKey(rw.Defender.Username)
+		if !ok {
+			slog.Info("No encryption key for defender, dropping war", "game", g.id, "defender", rw.Defender.Username)
+			return pubsub.NackDiscard
+		}
+		exchange := routing.ExchangePerilDirect
+		key := routing.GameKey(g.id, routing.DeclaredWarKey, rw.Defender.Username)
+		if err := pubsub.PublishJSON(g.ch, exchange, key, rw, pubsub.WithEncryption(recipient), pubsub.WithSigner(g.world.signer)); err != nil {
+			slog.Error("Failed to publish declared war", "error", err)
+			return pubsub.NackRequeue
+		}
+
+		exchange = routing.ExchangePerilTopic
+		key = routing.GameKey(g.id, routing.WarRecognitionsPrefix, rw.Defender.Username)
+		if err := pubsub.PublishJSON(g.ch, exchange, key, rw, pubsub.WithSigner(g.world.signer)); err != nil {
+			slog.Error("Failed to publish war", "error", err)
+			return pubsub.NackRequeue
+		}
+		return pubsub.Ack
+	}
+}
+
+func handlerWarResults(g *game) func(gamelogic.BattleResult) pubsub.AckType {
+	return func(br gamelogic.BattleResult) pubsub.AckType {
+		battles, err := g.wars.report(br)
+		if err != nil {
+			slog.Warn("Rejected battle report", "game", g.id, "attacker", br.Attacker, "reason", err)
+			return pubsub.NackDiscard
+		}
+		return g.recordWar(battles)
+	}
+}
+
+func handlerBattleReports(g *game) func(gamelogic.BattleResult) pubsub.AckType {
+	return func(br gamelogic.BattleResult) pubsub.AckType {
+		battles, err := g.wars.confirm(br)
+		if err != nil {
+			slog.Warn("Rejected battle confirmation", "game", g.id, "defender", br.Defender, "reason", err)
+			return pubsub.NackDiscard
+		}
+		return g.recordWar(battles)
+	}
+}
+
+func (g *game) recordWar(battles []gamelogic.BattleResult) pubsub.AckType {
+	for _, br := range battles {
+		g.world.applyBattle(br)
+	}
+	if err := g.store.RecordWar(g.id, battles, time.Now()); err != nil {
+		slog.Error("Failed to save war", "game", g.id, "error", err)
+		return pubsub.NackDiscard
+	}
+	for _, br := range battles {
+		g.world.dash.war(g.id, br)
+	}
+	if err := g.relayBattles(battles); err != nil {
+		slog.Error("Failed to relay battles to allies", "game", g.id, "error", err)
+	}
+	return pubsub.Ack
+}
+
+func (g *game) relayBattles(battles []gamelogic.BattleResult) error {
+	for _, br := range battles {
+		for _, ally := range br.Allies {
+			recipient, ok := g.world.keys.EncryptionKey(ally)
+			if !ok {
+				continue
+			}
+
+			exchange := routing.ExchangePerilDirect
+			key := routing.GameKey(g.id, routing.ConfirmedBattlesKey, ally)
+			if err := pubsub.PublishJSON(g.ch, exchange, key, br, pubsub.WithEncryption(recipient), pubsub.WithSigner(g.world.signer)); err != nil {
+				err := fmt.Errorf("failed to relay battle of %s to %s: %w", br.Location, ally, err)
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func lobbySender(_ string, req routing.LobbyRequest) string {
+	return req.Username
+}
+
+func handlerLobby(s *server) func(routing.LobbyRequest) pubsub.AckType {
+	return func(req routing.LobbyRequest) pubsub.AckType {
+		defer fmt.Print("> ")
+		resp := routing.LobbyResponse{Action: req.Action}
+
+		var err error
+		switch {
+		case !s.sessions.valid(req.Username, req.Token):
+			err = errors.New("invalid session, please rejoin")
+		case req.Action == routing.LobbyList:
+			resp.Games = s.listGames()
+		case req.Action == routing.LobbyCreate:
+			_, err = s.createGame(req.GameID)
+			resp.GameID = req.GameID
+		case req.Action == routing.LobbyJoin:
+			if _, ok := s.getGame(req.GameID); !ok {
+				err = fmt.Errorf("game %s does not exist", req.GameID)
+			}
+			resp.GameID = req.GameID
+		case req.Action == routing.LobbyMatch:
+			resp.GameID, err = s.matchGame(req.Username)
+		default:
+			err = errors.New("unknown lobby action")
+		}
+		if err != nil {
+			resp.Error = err.Error()
+		}
+
+		slog.Info("Lobby request", "username", req.Username, "action", req.Action, "game", req.GameID, "error", resp.Error)
+		exchange := routing.ExchangePerilDirect
+		key := routing.LobbyKey + "." + req.Username
+		if err := pubsub.PublishJSON(s.ch, exchange, key, resp, pubsub.WithSigner(s.signer)); err != nil {
+			slog.Error("Failed to publish lobby response", "error", err)
+			return pubsub.NackRequeue
+		}
+
+		return pubsub.Ack
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+)
+
+const (
+	matchMaxPlayers  = 4
+	matchRatingRange = 400.0
+)
+
+func (s *server) matchGame(username string) (string, error) {
+	rating, err := s.store.Rating(username)
+	if err != nil {
+		return "", err
+	}
+
+	best, bestGap := "", math.Inf(1)
+	for _, info := range s.listGames() {
+		if slices.Contains(info.Players, username) {
+			return info.ID, nil
+		}
+		if info.Ended || len(info.Players) == 0 || len(info.Players) >= matchMaxPlayers {
+			continue
+		}
+
+		ratings, err := s.store.Ratings(info.Players)
+		if err != nil {
+			return "", err
+		}
+		total := 0.0
+		for _, r := range ratings {
+			total += r
+		}
+		gap := math.Abs(total/float64(len(ratings)) - rating)
+		if gap <= matchRatingRange && gap < bestGap {
+			best, bestGap = info.ID, gap
+		}
+	}
+	if best != "" {
+		slog.Info("Matched player", "username", username, "rating", rating, "game", best, "gap", bestGap)
+		return best, nil
+	}
+
+	suffix, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	id := "match-" + suffix[:8]
+	if _, err := s.createGame(id); err != nil {
+		err := fmt.Errorf("could not create a game to match into: %w", err)
+		return "", err
+	}
+	slog.Info("Matched player into a new game", "username", username, "rating", rating, "game", id)
+	return id, nil
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	logRate           = 1.0
+	logBurst          = 5
+	moveRate          = 2.0
+	moveBurst         = 10
+	chatRate          = 1.0
+	chatBurst         = 5
+	strikesBeforeMute = 10
+	strikeWindow      = time.Minute
+	muteDuration      = 30 * time.Second
+)
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type rateLimiter struct {
+	rate    float64
+	burst   float64
+	buckets map[string]*tokenBucket
+	mu      *sync.Mutex
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: map[string]*tokenBucket{},
+		mu:      &sync.Mutex{},
+	}
+}
+
+func (rl *rateLimiter) allow(username string) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	bucket, ok := rl.buckets[username]
+	if !ok {
+		bucket = &tokenBucket{tokens: rl.burst, last: now}
+		rl.buckets[username] = bucket
+	}
+	bucket.tokens = math.Min(rl.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*rl.rate)
+	bucket.last = now
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+type strikeRecord struct {
+	count int
+	first time.Time
+}
+
+type moderator struct {
+	ch      *amqp.Channel
+	signer  pubsub.Signer
+	logs    *rateLimiter
+	moves   *rateLimiter
+	chat    *rateLimiter
+	bans    *banList
+	strikes map[string]strikeRecord
+	muted   map[string]time.Time
+	mu      *sync.Mutex
+}
+
+func newModerator(ch *amqp.Channel, signer pubsub.Signer, bans *banList) *moderator {
+	return &moderator{
+		ch:      ch,
+		signer:  signer,
+		logs:    newRateLimiter(logRate, logBurst),
+		moves:   newRateLimiter(moveRate, moveBurst),
+		chat:    newRateLimiter(chatRate, chatBurst),
+		bans:    bans,
+		strikes: map[string]strikeRecord{},
+		muted:   map[string]time.Time{},
+		mu:      &sync.Mutex{},
+	}
+}
+
+func (m *moderator) admitLog(_ string, gl routing.GameLog) error {
+	return m.admit(m.logs, gl.Username, "game logs")
+}
+
+func (m *moderator) admitMove(_ string, move gamelogic.ArmyMove) error {
+	return m.admit(m.moves, move.Player.Username, "moves")
+}
+
+func (m *moderator) admitChat(_ string, msg routing.ChatMessage) error {
+	return m.admit(m.chat, msg.From, "chat")
+}
+
+func (m *moderator) admit(limiter *rateLimiter, username, stream string) error {
+	if record, ok := m.bans.banned(username); ok {
+		return errors.New(record.String())
+	}
+	if until, ok := m.mutedUntil(username); ok {
+		return fmt.Errorf("%s is muted until %s", username, until.Format(time.TimeOnly))
+	}
+	if limiter.allow(username) {
+		return nil
+	}
+
+	reason := fmt.Sprintf("rate limit exceeded for %s", stream)
+	action, until := m.strike(username)
+	switch action {
+	case routing.ModerationWarn:
+		slog.Info("Rate limit exceeded", "username", username, "stream", stream)
+	case routing.ModerationMute:
+		slog.Info("Player muted for flooding", "username", username, "stream", stream, "until", until)
+	default:
+		return fmt.Errorf("%s: %s", username, reason)
+	}
+	if err := m.notify(routing.Moderation{
+		Username: username,
+		Action:   action,
+		Reason:   reason,
+		Until:    until,
+	}); err != nil {
+		slog.Error("Failed to notify player", "username", username, "error", err)
+	}
+	return fmt.Errorf("%s: %s", username, reason)
+}
+
+func (m *moderator) strike(username string) (routing.ModerationAction, time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record := m.strikes[username]
+	if time.Since(record.first) > strikeWindow {
+		record = strikeRecord{first: time.Now()}
+	}
+	record.count++
+	m.strikes[username] = record
+
+	switch {
+	case record.count == 1:
+		return routing.ModerationWarn, time.Time{}
+	case record.count >= strikesBeforeMute:
+		delete(m.strikes, username)
+		until := time.Now().Add(muteDuration)
+		m.muted[username] = until
+		return routing.ModerationMute, until
+	}
+	return "", time.Time{}
+}
+
+func (m *moderator) mute(username string, until time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.muted[username] = until
+}
+
+func (m *moderator) unmute(username string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.muted[username]
+	delete(m.muted, username)
+	return ok
+}
+
+func (m *moderator) mutedUntil(username string) (time.Time, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	until, ok := m.muted[username]
+	if !ok {
+		return time.Time{}, false
+	}
+	if time.Now().After(until) {
+		delete(m.muted, username)
+		return time.Time{}, false
+	}
+	return until, true
+}
+
+func (m *moderator) notify(msg routing.Moderation) error {
+	exchange := routing.ExchangePerilDirect
+	key := routing.ModerationKey + "." + msg.Username
+	if err := pubsub.PublishJSON(m.ch, exchange, key, msg, pubsub.WithSigner(m.signer)); err != nil {
+		err := fmt.Errorf("failed to publish JSON: %w", err)
+		return err
+	}
+	return nil
+}
+
+func (s *server) kick(username, reason string) error {
+	s.sessions.drop(username)
+	return s.moderator.notify(routing.Moderation{
+		Username: username,
+		Action:   routing.ModerationKick,
+		Reason:   reason,
+	})
+}
+
+func (s *server) ban(username string, duration time.Duration) error {
+	record := banRecord{Username: username, Reason: "banned by an administrator"}
+	if duration > 0 {
+		record.Until = time.Now().Add(duration)
+	}
+	if err := s.moderator.bans.ban(record); err != nil {
+		err := fmt.Errorf("failed to save ban: %w", err)
+		return err
+	}
+
+	s.sessions.drop(username)
+	return s.moderator.notify(routing.Moderation{
+		Username: username,
+		Action:   routing.ModerationBan,
+		Reason:   record.Reason,
+		Until:    record.Until,
+	})
+}
+
+func (s *server) mute(username string, duration time.Duration) error {
+	until := time.Now().Add(duration)
+	s.moderator.mute(username, until)
+	return s.moderator.notify(routing.Moderation{
+		Username: username,
+		Action:   routing.ModerationMute,
+		Reason:   "muted by an administrator",
+		Until:    until,
+	})
+}
+
+func (s *server) unban(username string) (bool, error) {
+	unmuted := s.moderator.unmute(username)
+	unbanned, err := s.moderator.bans.unban(username)
+	if err != nil {
+		err := fmt.Errorf("failed to save ban list: %w", err)
+		return false, err
+	}
+	if unmuted {
+		if err := s.moderator.notify(routing.Moderation{
+			Username: username,
+			Action:   routing.ModerationUnmute,
+			Reason:   "unmuted by an administrator",
+		}); err != nil {
+			return true, err
+		}
+	}
+	return unmuted || unbanned, nil
+}
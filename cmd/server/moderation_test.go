@@ -0,0 +1,98 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	t.Run("allows a burst then refuses", func(t *testing.T) {
+		rl := newRateLimiter(0.001, 3)
+		for i := range 3 {
+			if !rl.allow("alice") {
+				t.Fatalf("message %d within the burst was refused", i+1)
+			}
+		}
+		if rl.allow("alice") {
+			t.Fatal("message beyond the burst was allowed")
+		}
+	})
+
+	t.Run("tracks players separately", func(t *testing.T) {
+		rl := newRateLimiter(0.001, 1)
+		if !rl.allow("alice") || !rl.allow("bob") {
+			t.Fatal("first message from each player was refused")
+		}
+		if rl.allow("alice") || rl.allow("bob") {
+			t.Fatal("second message from a player was allowed")
+		}
+	})
+
+	t.Run("refills over time", func(t *testing.T) {
+		rl := newRateLimiter(100, 1)
+		if !rl.allow("alice") {
+			t.Fatal("first message was refused")
+		}
+		if rl.allow("alice") {
+			t.Fatal("message before the refill was allowed")
+		}
+		time.Sleep(20 * time.Millisecond)
+		if !rl.allow("alice") {
+			t.Fatal("message after the refill was refused")
+		}
+	})
+
+	t.Run("never stores more than the burst", func(t *testing.T) {
+		rl := newRateLimiter(1000, 2)
+		rl.allow("alice")
+		time.Sleep(20 * time.Millisecond)
+		allowed := 0
+		for range 5 {
+			if rl.allow("alice") {
+				allowed++
+			}
+		}
+		if allowed > 3 {
+			t.Fatalf("allowed %d messages after idling, want at most the burst", allowed)
+		}
+	})
+}
+
+func TestAdmitChat(t *testing.T) {
+	bans, err := loadBanList(filepath.Join(t.TempDir(), "bans.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bans.ban(banRecord{Username: "mallory", Reason: "spam"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := bans.ban(banRecord{Username: "eve", Until: time.Now().Add(-time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	m := newModerator(nil, pubsub.Signer{}, bans)
+	m.muted["bob"] = time.Now().Add(time.Minute)
+	m.muted["carol"] = time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		from    string
+		wantErr bool
+	}{
+		{"alice", false},
+		{"mallory", true},
+		{"eve", false},
+		{"bob", true},
+		{"carol", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from, func(t *testing.T) {
+			err := m.admitChat("default.chat.global", routing.ChatMessage{From: tt.from, Channel: routing.ChatGlobal, Message: "hi"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("admitChat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const presenceTimeout = 3 * routing.HeartbeatInterval
+
+type presence struct {
+	gameID  string
+	ch      *amqp.Channel
+	signer  pubsub.Signer
+	entries map[string]routing.PresenceEntry
+	mu      *sync.Mutex
+}
+
+func newPresence(gameID string, ch *amqp.Channel, signer pubsub.Signer) *presence {
+	return &presence{
+		gameID:  gameID,
+		ch:      ch,
+		signer:  signer,
+		entries: map[string]routing.PresenceEntry{},
+		mu:      &sync.Mutex{},
+	}
+}
+
+func (p *presence) seen(entry routing.PresenceEntry) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	previous, ok := p.entries[entry.Username]
+	changed := !ok || !previous.Online ||
+		!bytes.Equal(previous.SigningKey, entry.SigningKey) ||
+		!bytes.Equal(previous.EncryptionKey, entry.EncryptionKey)
+	entry.Online = true
+	p.entries[entry.Username] = entry
+	return changed
+}
+
+func (p *presence) leave(username string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry, ok := p.entries[username]
+	if !ok || !entry.Online {
+		return false
+	}
+	entry.Online = false
+	p.entries[username] = entry
+	return true
+}
+
+func (p *presence) sweep(timeout time.Duration) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	expired := []string{}
+	for username, entry := range p.entries {
+		if entry.Online && time.Since(entry.LastSeen) > timeout {
+			entry.Online = false
+			p.entries[username] = entry
+			expired = append(expired, username)
+		}
+	}
+	slices.Sort(expired)
+	return expired
+}
+
+func (p *presence) list() []routing.PresenceEntry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entries := []routing.PresenceEntry{}
+	for _, entry := range p.entries {
+		entries = append(entries, entry)
+	}
+	slices.SortFunc(entries, func(a, b routing.PresenceEntry) int {
+		return strings.Compare(a.Username, b.Username)
+	})
+	return entries
+}
+
+func (p *presence) isOnline(username string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.entries[username].Online
+}
+
+func (p *presence) run() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		for _, username := range p.sweep(presenceTimeout) {
+			slog.Info("Player timed out", "game", p.gameID, "username", username)
+			if err := p.broadcast(username, false); err != nil {
+				slog.Error("Failed to broadcast roster", "error", err)
+			}
+		}
+	}
+}
+
+func (p *presence) broadcast(username string, online bool) error {
+	exchange := routing.ExchangePerilTopic
+	key := routing.GameKey(p.gameID, routing.RosterKey)
+	if err := pubsub.PublishJSON(p.ch, exchange, key, routing.Roster{
+		GameID:   p.gameID,
+		Username: username,
+		Online:   online,
+		Players:  p.list(),
+	}, pubsub.WithSigner(p.signer)); err != nil {
+		err := fmt.Errorf("failed to publish JSON: %w", err)
+		return err
+	}
+
+	return nil
+}
+
+func (p *presence) print() {
+	entries := p.list()
+	if len(entries) == 0 {
+		fmt.Printf("No players have joined %s yet.\n", p.gameID)
+		return
+	}
+	for _, entry := range entries {
+		status := "offline"
+		if entry.Online {
+			status = "online"
+		}
+		fmt.Printf("* %s: %s, last seen %s\n", entry.Username, status, entry.LastSeen.Format(time.TimeOnly))
+	}
+}
+
+func presenceSender(_ string, msg routing.Presence) string {
+	return msg.Username
+}
+
+func handlerPresence(p *presence, sess *sessions) func(routing.Presence) pubsub.AckType {
+	return func(msg routing.Presence) pubsub.AckType {
+		if time.Since(msg.SentAt) > presenceTimeout {
+			slog.Info("Rejected stale presence", "game", p.gameID, "username", msg.Username, "sent_at", msg.SentAt)
+			return pubsub.NackDiscard
+		}
+		if !sess.touch(msg.Username, msg.Token) {
+			slog.Info("Rejected presence with an invalid session", "game", p.gameID, "username", msg.Username)
+			return pubsub.NackDiscard
+		}
+
+		var changed, online bool
+		switch msg.Event {
+		case routing.PresenceJoin, routing.PresenceHeartbeat:
+			entry := routing.PresenceEntry{Username: msg.Username, LastSeen: time.Now()}
+			entry.SigningKey, _ = sess.PublicKey(msg.Username)
+			if key, ok := sess.EncryptionKey(msg.Username); ok {
+				entry.EncryptionKey = key.Bytes()
+			}
+			changed, online = p.seen(entry), true
+		case routing.PresenceLeave:
+			sess.close(msg.Username, msg.Token)
+			changed, online = p.leave(msg.Username), false
+		default:
+			slog.Error("Unknown presence event", "event", msg.Event)
+			return pubsub.NackDiscard
+		}
+		if !changed {
+			return pubsub.Ack
+		}
+
+		defer fmt.Print("> ")
+		slog.Info("Roster changed", "game", p.gameID, "username", msg.Username, "online", online)
+		if err := p.broadcast(msg.Username, online); err != nil {
+			slog.Error("Failed to broadcast roster", "error", err)
+			return pubsub.NackRequeue
+		}
+		return pubsub.Ack
+	}
+}
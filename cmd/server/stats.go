@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+const leaderboardSize = 10
+
+func (s *server) territories() map[string]int {
+	s.mu.RLock()
+	games := []*game{}
+	for _, g := range s.games {
+		games = append(games, g)
+	}
+	s.mu.RUnlock()
+
+	held := map[string]int{}
+	for _, g := range games {
+		for username, player := range g.world.snapshot() {
+			locations := map[gamelogic.Location]struct{}{}
+			for _, unit := range player.Units {
+				locations[unit.Location] = struct{}{}
+			}
+			held[username] += len(locations)
+		}
+	}
+	return held
+}
+
+func (s *server) leaderboard() ([]routing.PlayerStats, error) {
+	leaderboard, err := s.store.Leaderboard(leaderboardSize)
+	if err != nil {
+		return nil, err
+	}
+	held := s.territories()
+	for i := range leaderboard {
+		leaderboard[i].Territories = held[leaderboard[i].Username]
+	}
+	return leaderboard, nil
+}
+
+func (s *server) playerStats(username string) (routing.PlayerStats, error) {
+	stats, err := s.store.PlayerStats(username)
+	if err != nil {
+		return routing.PlayerStats{}, err
+	}
+	stats.Territories = s.territories()[username]
+	return stats, nil
+}
+
+func statsSender(_ string, req routing.StatsRequest) string {
+	return req.Username
+}
+
+func handlerStats(s *server) func(routing.StatsRequest) pubsub.AckType {
+	return func(req routing.StatsRequest) pubsub.AckType {
+		defer fmt.Print("> ")
+		target := req.Target
+		if target == "" {
+			target = req.Username
+		}
+
+		resp := routing.StatsResponse{}
+		stats, err := s.playerStats(target)
+		if err == nil {
+			resp.Stats = stats
+			resp.Leaderboard, err = s.leaderboard()
+		}
+		if err != nil {
+			slog.Error("Failed to load stats", "username", target, "error", err)
+			resp.Error = "stats are unavailable right now"
+		}
+
+		exchange := routing.ExchangePerilDirect
+		key := routing.StatsKey + "." + req.Username
+		if err := pubsub.PublishJSON(s.ch, exchange, key, resp, pubsub.WithSigner(s.signer)); err != nil {
+			slog.Error("Failed to publish stats", "error", err)
+			return pubsub.NackRequeue
+		}
+		return pubsub.Ack
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const turnClockResolution = 250 * time.Millisecond
+
+type turnClock struct {
+	ch        *amqp.Channel
+	world     *world
+	length    time.Duration
+	turn      int
+	deadline  time.Time
+	remaining time.Duration
+	paused    bool
+	orders    []gamelogic.ArmyMove
+	mu        *sync.Mutex
+}
+
+func newTurnClock(ch *amqp.Channel, w *world, length time.Duration) *turnClock {
+	return &turnClock{
+		ch:     ch,
+		world:  w,
+		length: length,
+		orders: []gamelogic.ArmyMove{},
+		mu:     &sync.Mutex{},
+	}
+}
+
+func (tc *turnClock) run() {
+	tc.mu.Lock()
+	tc.turn = 1
+	tc.deadline = time.Now().Add(tc.length)
+	turn := tc.snapshot(nil)
+	tc.mu.Unlock()
+
+	if err := tc.publish(turn); err != nil {
+		slog.Error("Failed to announce first turn", "error", err)
+	}
+
+	ticker := time.NewTicker(turnClockResolution)
+	defer ticker.Stop()
+	for range ticker.C {
+		if _, over := tc.world.outcome(); over {
+			return
+		}
+		tc.mu.Lock()
+		if tc.paused || time.Now().Before(tc.deadline) {
+			tc.mu.Unlock()
+			continue
+		}
+		orders := tc.orders
+		tc.orders = []gamelogic.ArmyMove{}
+		tc.turn++
+		tc.deadline = time.Now().Add(tc.length)
+		tc.mu.Unlock()
+
+		resolved := []gamelogic.ArmyMove{}
+		for _, move := range orders {
+			if err := tc.world.apply(move.Player); err != nil {
+				slog.Info("Dropped invalid order", "username", move.Player.Username, "reason", err)
+				continue
+			}
+			tc.world.dash.move(tc.world.gameID, move)
+			resolved = append(resolved, move)
+		}
+
+		tc.mu.Lock()
+		turn := tc.snapshot(resolved)
+		tc.mu.Unlock()
+
+		slog.Info("Turn resolved", "turn", turn.Number-1, "orders", len(resolved))
+		if err := tc.publish(turn); err != nil {
+			slog.Error("Failed to announce turn", "turn", turn.Number, "error", err)
+		}
+	}
+}
+
+func (tc *turnClock) pause() {
+	tc.mu.Lock()
+	if tc.paused {
+		tc.mu.Unlock()
+		return
+	}
+	tc.paused = true
+	tc.remaining = time.Until(tc.deadline)
+	turn := tc.snapshot(nil)
+	tc.mu.Unlock()
+
+	if err := tc.publish(turn); err != nil {
+		slog.Error("Failed to announce paused turn", "error", err)
+	}
+}
+
+func (tc *turnClock) resume() {
+	tc.mu.Lock()
+	if !tc.paused {
+		tc.mu.Unlock()
+		return
+	}
+	tc.paused = false
+	tc.deadline = time.Now().Add(tc.remaining)
+	turn := tc.snapshot(nil)
+	tc.mu.Unlock()
+
+	if err := tc.publish(turn); err != nil {
+		slog.Error("Failed to announce resumed turn", "error", err)
+	}
+}
+
+func (tc *turnClock) announce() error {
+	tc.mu.Lock()
+	turn := tc.snapshot(nil)
+	tc.mu.Unlock()
+	return tc.publish(turn)
+}
+
+func (tc *turnClock) submit(move gamelogic.ArmyMove) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if tc.paused {
+		return fmt.Errorf("turn %d is paused", tc.turn)
+	}
+	if move.Turn != tc.turn {
+		return fmt.Errorf("order is for turn %d but the current turn is %d", move.Turn, tc.turn)
+	}
+	tc.orders = append(tc.orders, move)
+	return nil
+}
+
+func (tc *turnClock) snapshot(resolved []gamelogic.ArmyMove) gamelogic.Turn {
+	deadline := tc.deadline
+	if tc.paused {
+		deadline = time.Now().Add(tc.remaining)
+	}
+	return gamelogic.Turn{
+		Number:   tc.turn,
+		Deadline: deadline,
+		IsPaused: tc.paused,
+		Resolved: resolved,
+	}
+}
+
+func (tc *turnClock) publish(turn gamelogic.Turn) error {
+	for username, observer := range tc.world.snapshot() {
+		recipient, ok := tc.world.keys.EncryptionKey(username)
+		if !ok {
+			continue
+		}
+
+		exchange := routing.ExchangePerilDirect
+		key := routing.GameKey(tc.world.gameID, routing.TurnKey, username)
+		if err := pubsub.PublishJSON(tc.ch, exchange, key, tc.world.redactTurn(turn, observer), pubsub.WithEncryption(recipient), pubsub.WithSigner(tc.world.signer)); err != nil {
+			err := fmt.Errorf("failed to publish turn to %s: %w", username, err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func handlerOrders(tc *turnClock) func(gamelogic.ArmyMove) pubsub.AckType {
+	return func(move gamelogic.ArmyMove) pubsub.AckType {
+		defer fmt.Print("> ")
+		if _, over := tc.world.outcome(); over {
+			slog.Info("Rejected order after the game ended", "username", move.Player.Username)
+			return pubsub.NackDiscard
+		}
+		if _, err := tc.world.validate(move.Player); err != nil {
+			slog.Info("Rejected invalid order", "username", move.Player.Username, "reason", err)
+			return pubsub.NackDiscard
+		}
+		if err := tc.submit(move); err != nil {
+			slog.Info("Rejected order", "username", move.Player.Username, "reason", err)
+			return pubsub.NackDiscard
+		}
+
+		slog.Info("Order received", "username", move.Player.Username, "turn", move.Turn)
+		return pubsub.Ack
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+func (g *game) watchVictory() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		over, ok := g.scenario.CheckVictory(g.world.snapshot(), g.world.contenders(), time.Since(g.started)-g.world.pausedFor())
+		if !ok {
+			continue
+		}
+		over.GameID = g.id
+		over.EndedAt = time.Now()
+		if err := g.finish(over); err != nil {
+			slog.Error("Failed to end game", "game", g.id, "error", err)
+		}
+		return
+	}
+}
+
+func (g *game) finish(over routing.GameOver) error {
+	if !g.world.end(over) {
+		return nil
+	}
+
+	slog.Info("Game over", "game", g.id, "winner", over.Winner, "reason", over.Reason)
+	g.world.dash.gameOver(over)
+	if err := g.store.EndGame(over); err != nil {
+		slog.Error("Failed to save game over", "game", g.id, "error", err)
+	}
+	return g.publishGameOver(over)
+}
+
+func (g *game) publishGameOver(over routing.GameOver) error {
+	exchange := routing.ExchangePerilDirect
+	key := routing.GameKey(g.id, routing.GameOverKey)
+	if err := pubsub.PublishJSON(g.ch, exchange, key, over, pubsub.WithSigner(g.world.signer)); err != nil {
+		err := fmt.Errorf("failed to publish JSON: %w", err)
+		return err
+	}
+
+	return nil
+}
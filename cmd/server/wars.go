@@ -0,0 +1,142 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+)
+
+const warConfirmTimeout = time.Minute
+
+type warKey struct {
+	war      int64
+	attacker string
+	defender string
+}
+
+type pendingWar struct {
+	fronts       int
+	reports      map[gamelogic.Location]gamelogic.BattleResult
+	confirmation map[gamelogic.Location]gamelogic.BattleResult
+	started      time.Time
+}
+
+type warLedger struct {
+	gameID   string
+	wars     map[warKey]*pendingWar
+	declared map[warKey]time.Time
+	mu       *sync.Mutex
+}
+
+func newWarLedger(gameID string) *warLedger {
+	return &warLedger{
+		gameID:   gameID,
+		wars:     map[warKey]*pendingWar{},
+		declared: map[warKey]time.Time{},
+		mu:       &sync.Mutex{},
+	}
+}
+
+func (wl *warLedger) declare(rw gamelogic.RecognitionOfWar) (int64, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		err := fmt.Errorf("could not roll war seed: %w", err)
+		return 0, err
+	}
+	seed := int64(binary.BigEndian.Uint64(buf) >> 1)
+
+	wl.mu.Lock()
+	defer wl.mu.Unlock()
+	wl.expire()
+	wl.declared[warKey{war: seed, attacker: rw.Attacker.Username, defender: rw.Defender.Username}] = time.Now()
+	return seed, nil
+}
+
+func (wl *warLedger) report(br gamelogic.BattleResult) ([]gamelogic.BattleResult, error) {
+	return wl.add(br, false)
+}
+
+func (wl *warLedger) confirm(br gamelogic.BattleResult) ([]gamelogic.BattleResult, error) {
+	return wl.add(br, true)
+}
+
+func (wl *warLedger) add(br gamelogic.BattleResult, confirmation bool) ([]gamelogic.BattleResult, error) {
+	if br.Fronts <= 0 {
+		return nil, fmt.Errorf("battle of %s is not part of a war", br.Location)
+	}
+
+	wl.mu.Lock()
+	defer wl.mu.Unlock()
+	wl.expire()
+
+	key := warKey{war: br.War, attacker: br.Attacker, defender: br.Defender}
+	if _, ok := wl.declared[key]; !ok {
+		return nil, fmt.Errorf("battle of %s belongs to a war the server did not declare", br.Location)
+	}
+	pw, ok := wl.wars[key]
+	if !ok {
+		pw = &pendingWar{
+			fronts:       br.Fronts,
+			reports:      map[gamelogic.Location]gamelogic.BattleResult{},
+			confirmation: map[gamelogic.Location]gamelogic.BattleResult{},
+			started:      time.Now(),
+		}
+		wl.wars[key] = pw
+	}
+	if pw.fronts != br.Fronts {
+		wl.forget(key)
+		return nil, fmt.Errorf("battle of %s claims %d fronts but the war has %d", br.Location, br.Fronts, pw.fronts)
+	}
+
+	own, other := pw.reports, pw.confirmation
+	if confirmation {
+		own, other = pw.confirmation, pw.reports
+	}
+	own[br.Location] = br
+	if match, ok := other[br.Location]; ok && !gamelogic.SameBattle(br, match) {
+		wl.forget(key)
+		return nil, fmt.Errorf("%s and %s disagree about the battle of %s", br.Attacker, br.Defender, br.Location)
+	}
+
+	battles := []gamelogic.BattleResult{}
+	for loc, report := range pw.reports {
+		if _, ok := pw.confirmation[loc]; ok {
+			battles = append(battles, report)
+		}
+	}
+	if len(battles) < pw.fronts {
+		return nil, nil
+	}
+	wl.forget(key)
+	slices.SortFunc(battles, func(a, b gamelogic.BattleResult) int {
+		return strings.Compare(string(a.Location), string(b.Location))
+	})
+	return battles, nil
+}
+
+func (wl *warLedger) forget(key warKey) {
+	delete(wl.wars, key)
+	delete(wl.declared, key)
+}
+
+func (wl *warLedger) expire() {
+	for key, declared := range wl.declared {
+		if _, pending := wl.wars[key]; !pending && time.Since(declared) >= warConfirmTimeout {
+			delete(wl.declared, key)
+		}
+	}
+	for key, pw := range wl.wars {
+		if time.Since(pw.started) < warConfirmTimeout {
+			continue
+		}
+		slog.Warn("Dropped a war that was never confirmed by both sides", "game", wl.gameID, "attacker", key.attacker, "defender", key.defender)
+		wl.forget(key)
+	}
+}
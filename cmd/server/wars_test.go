@@ -0,0 +1,114 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+)
+
+func TestWarLedger(t *testing.T) {
+	var war int64
+	battle := func(loc gamelogic.Location, winner string) gamelogic.BattleResult {
+		return gamelogic.BattleResult{War: war, Fronts: 2, Location: loc, Attacker: "alice", Defender: "bob", Winner: winner}
+	}
+	newDeclaredLedger := func(t *testing.T) *warLedger {
+		t.Helper()
+		wl := newWarLedger("test")
+		seed, err := wl.declare(gamelogic.RecognitionOfWar{
+			Attacker: gamelogic.Player{Username: "alice"},
+			Defender: gamelogic.Player{Username: "bob"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		war = seed
+		return wl
+	}
+
+	t.Run("records a war once both sides agree on every front", func(t *testing.T) {
+		wl := newDeclaredLedger(t)
+		steps := []struct {
+			br      gamelogic.BattleResult
+			confirm bool
+			want    int
+		}{
+			{battle("europe", "alice"), false, 0},
+			{battle("europe", "alice"), true, 0},
+			{battle("asia", "bob"), true, 0},
+			{battle("asia", "bob"), false, 2},
+		}
+		for i, step := range steps {
+			battles, err := wl.add(step.br, step.confirm)
+			if err != nil {
+				t.Fatalf("step %d: unexpected error: %v", i, err)
+			}
+			if len(battles) != step.want {
+				t.Fatalf("step %d: got %d battles, want %d", i, len(battles), step.want)
+			}
+		}
+		if len(wl.wars) != 0 || len(wl.declared) != 0 {
+			t.Errorf("recorded war is still pending")
+		}
+	})
+
+	t.Run("rejects reports the defender disputes", func(t *testing.T) {
+		wl := newDeclaredLedger(t)
+		if _, err := wl.report(battle("europe", "alice")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := wl.confirm(battle("europe", "bob")); err == nil {
+			t.Fatal("expected an error for mismatching reports")
+		}
+		if len(wl.wars) != 0 {
+			t.Errorf("disputed war is still pending")
+		}
+	})
+
+	t.Run("never records a war the defender did not confirm", func(t *testing.T) {
+		wl := newDeclaredLedger(t)
+		for _, loc := range []gamelogic.Location{"europe", "asia"} {
+			battles, err := wl.report(battle(loc, "alice"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(battles) != 0 {
+				t.Fatalf("recorded %d battles without a confirmation", len(battles))
+			}
+		}
+	})
+
+	t.Run("rejects battles that disagree about the fronts", func(t *testing.T) {
+		wl := newDeclaredLedger(t)
+		if _, err := wl.report(battle("europe", "alice")); err != nil {
+			t.Fatal(err)
+		}
+		br := battle("asia", "bob")
+		br.Fronts = 1
+		if _, err := wl.confirm(br); err == nil {
+			t.Fatal("expected an error for a mismatching front count")
+		}
+	})
+
+	t.Run("rejects wars the server did not declare", func(t *testing.T) {
+		wl := newDeclaredLedger(t)
+		br := battle("europe", "alice")
+		br.War++
+		if _, err := wl.report(br); err == nil {
+			t.Fatal("expected an error for an undeclared war")
+		}
+		br = battle("europe", "alice")
+		br.Attacker = "carol"
+		if _, err := wl.report(br); err == nil {
+			t.Fatal("expected an error for a war declared against another attacker")
+		}
+	})
+
+	t.Run("rejects battles outside a war", func(t *testing.T) {
+		wl := newDeclaredLedger(t)
+		br := battle("europe", "alice")
+		br.Fronts = 0
+		if _, err := wl.report(br); err == nil {
+			t.Fatal("expected an error for a battle without fronts")
+		}
+	})
+}
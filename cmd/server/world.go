@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+type world struct {
+	gameID   string
+	scenario gamelogic.Scenario
+	signer   pubsub.Signer
+	keys     pubsub.EncryptionKeys
+	players  map[string]gamelogic.Player
+	fielded  map[string]struct{}
+	balances map[string]int
+	paused   bool
+	pausedAt time.Time
+	idle     time.Duration
+	result   *routing.GameOver
+	dash     *dashboard
+	mu       *sync.RWMutex
+}
+
+func newWorld(gameID string, scenario gamelogic.Scenario, signer pubsub.Signer, keys pubsub.EncryptionKeys, dash *dashboard) *world {
+	return &world{
+		gameID:   gameID,
+		scenario: scenario,
+		signer:   signer,
+		keys:     keys,
+		players:  map[string]gamelogic.Player{},
+		fielded:  map[string]struct{}{},
+		balances: map[string]int{},
+		dash:     dash,
+		mu:       &sync.RWMutex{},
+	}
+}
+
+func (w *world) register(username string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.players[username]; ok {
+		return
+	}
+	w.players[username] = gamelogic.Player{
+		Username:  username,
+		Units:     map[int]gamelogic.Unit{},
+		Resources: w.scenario.StartingResources,
+	}
+	w.balances[username] = w.scenario.StartingResources
+}
+
+func (w *world) validate(p gamelogic.Player) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.transition(p)
+}
+
+func (w *world) transition(p gamelogic.Player) (int, error) {
+	prev, ok := w.players[p.Username]
+	balance := w.balances[p.Username]
+	if !ok {
+		prev = gamelogic.Player{Username: p.Username, Units: map[int]gamelogic.Unit{}}
+		balance = w.scenario.StartingResources
+	}
+	_, deployed := w.fielded[p.Username]
+	budget := balance + w.scenario.Income(prev)
+	return w.scenario.ValidateTransition(prev, p, budget, !deployed)
+}
+
+func (w *world) apply(p gamelogic.Player) error {
+	w.mu.Lock()
+	cost, err := w.transition(p)
+	if err != nil {
+		w.mu.Unlock()
+		return err
+	}
+	if _, ok := w.players[p.Username]; !ok {
+		w.balances[p.Username] = w.scenario.StartingResources
+	}
+	w.balances[p.Username] -= cost
+	p.Resources = max(w.balances[p.Username], 0)
+	w.players[p.Username] = p
+	if len(p.Units) > 0 {
+		w.fielded[p.Username] = struct{}{}
+	}
+	w.mu.Unlock()
+	w.dash.world(w)
+	return nil
+}
+
+func (w *world) applyBattle(br gamelogic.BattleResult) {
+	w.mu.Lock()
+	for _, username := range append([]string{br.Attacker, br.Defender}, br.Allies...) {
+		p, ok := w.players[username]
+		if !ok {
+			continue
+		}
+		w.players[username], _ = w.scenario.ApplyBattle(p, br)
+	}
+	w.mu.Unlock()
+	w.dash.world(w)
+}
+
+func (w *world) collectIncome() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.paused || w.result != nil {
+		return
+	}
+	for username, p := range w.players {
+		w.balances[username] += w.scenario.Income(p)
+		p.Resources = max(w.balances[username], 0)
+		w.players[username] = p
+	}
+}
+
+func (w *world) setPaused(paused bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if paused == w.paused {
+		return
+	}
+	w.paused = paused
+	if paused {
+		w.pausedAt = time.Now()
+		return
+	}
+	w.idle += time.Since(w.pausedAt)
+}
+
+func (w *world) pausedFor() time.Duration {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.paused {
+		return w.idle + time.Since(w.pausedAt)
+	}
+	return w.idle
+}
+
+func (w *world) contenders() []string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	contenders := []string{}
+	for username := range w.fielded {
+		contenders = append(contenders, username)
+	}
+	slices.Sort(contenders)
+	return contenders
+}
+
+func (w *world) end(over routing.GameOver) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.result != nil {
+		return false
+	}
+	w.result = &over
+	return true
+}
+
+func (w *world) outcome() (routing.GameOver, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.result == nil {
+		return routing.GameOver{}, false
+	}
+	return *w.result, true
+}
+
+func (w *world) snapshot() map[string]gamelogic.Player {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	players := map[string]gamelogic.Player{}
+	for k, v := range w.players {
+		players[k] = v
+	}
+	return players
+}
+
+func (w *world) dispatchMove(ch *amqp.Channel, move gamelogic.ArmyMove) error {
+	w.dash.move(w.gameID, move)
+
+	for username, observer := range w.snapshot() {
+		if username == move.Player.Username {
+			continue
+		}
+		redacted, ok := w.scenario.RedactMove(move, observer)
+		if !ok {
+			continue
+		}
+		recipient, ok := w.keys.EncryptionKey(username)
+		if !ok {
+			slog.Info("No encryption key for player, skipping intel", "game", w.gameID, "username", username)
+			continue
+		}
+
+		exchange := routing.ExchangePerilTopic
+		key := routing.GameKey(w.gameID, routing.ArmyIntelPrefix, username)
+		if err := pubsub.PublishJSON(ch, exchange, key, redacted, pubsub.WithEncryption(recipient), pubsub.WithSigner(w.signer)); err != nil {
+			err := fmt.Errorf("failed to publish move to %s: %w", username, err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *world) redactTurn(turn gamelogic.Turn, observer gamelogic.Player) gamelogic.Turn {
+	resolved := []gamelogic.ArmyMove{}
+	for _, move := range turn.Resolved {
+		if move.Player.Username == observer.Username {
+			resolved = append(resolved, move)
+			continue
+		}
+		if redacted, ok := w.scenario.RedactMove(move, observer); ok {
+			resolved = append(resolved, redacted)
+		}
+	}
+	turn.Resolved = resolved
+	return turn
+}
+
+func moveSender(_ string, move gamelogic.ArmyMove) string {
+	return move.Player.Username
+}
+
+func playerSender(_ string, p gamelogic.Player) string {
+	return p.Username
+}
+
+func handlerArmyMoves(ch *amqp.Channel, w *world) func(gamelogic.ArmyMove) pubsub.AckType {
+	return func(move gamelogic.ArmyMove) pubsub.AckType {
+		defer fmt.Print("> ")
+		if _, over := w.outcome(); over {
+			slog.Info("Rejected move after the game ended", "game", w.gameID, "username", move.Player.Username)
+			return pubsub.NackDiscard
+		}
+		if err := w.apply(move.Player); err != nil {
+			slog.Info("Rejected invalid move", "game", w.gameID, "username", move.Player.Username, "reason", err)
+			return pubsub.NackDiscard
+		}
+		if err := w.dispatchMove(ch, move); err != nil {
+			slog.Error("Failed to dispatch move", "error", err)
+			return pubsub.NackRequeue
+		}
+
+		return pubsub.Ack
+	}
+}
+
+func handlerPlayerState(w *world) func(gamelogic.Player) pubsub.AckType {
+	return func(p gamelogic.Player) pubsub.AckType {
+		if err := w.apply(p); err != nil {
+			slog.Info("Rejected invalid player state", "game", w.gameID, "username", p.Username, "reason", err)
+			return pubsub.NackDiscard
+		}
+		return pubsub.Ack
+	}
+}
@@ -0,0 +1,105 @@
+package gamelogic
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+const chatHistoryLimit = 50
+
+func (gs *GameState) CommandSay(words []string) (routing.ChatMessage, error) {
+	if len(words) < 2 {
+		return routing.ChatMessage{}, errors.New("usage: say <message>")
+	}
+	return routing.ChatMessage{
+		GameID:  gs.GetGameID(),
+		Channel: routing.ChatGlobal,
+		From:    gs.GetUsername(),
+		Message: strings.Join(words[1:], " "),
+		SentAt:  time.Now(),
+	}, nil
+}
+
+func (gs *GameState) CommandWhisper(words []string) (routing.ChatMessage, error) {
+	if len(words) < 3 {
+		return routing.ChatMessage{}, errors.New("usage: whisper <username> <message>")
+	}
+	msg := routing.ChatMessage{
+		GameID:  gs.GetGameID(),
+		Channel: routing.ChatDirect,
+		From:    gs.GetUsername(),
+		To:      words[1],
+		Message: strings.Join(words[2:], " "),
+		SentAt:  time.Now(),
+	}
+	gs.recordChat(msg)
+	return msg, nil
+}
+
+func (gs *GameState) CommandAllies(words []string) ([]routing.ChatMessage, error) {
+	if len(words) < 2 {
+		return nil, errors.New("usage: allies <message>")
+	}
+	allies := gs.GetAllies()
+	if len(allies) == 0 {
+		return nil, errors.New("error: you have no allies to talk to")
+	}
+
+	sentAt := time.Now()
+	messages := []routing.ChatMessage{}
+	for _, ally := range allies {
+		messages = append(messages, routing.ChatMessage{
+			GameID:  gs.GetGameID(),
+			Channel: routing.ChatAlliance,
+			From:    gs.GetUsername(),
+			To:      ally,
+			Message: strings.Join(words[1:], " "),
+			SentAt:  sentAt,
+		})
+	}
+	gs.recordChat(messages[0])
+	return messages, nil
+}
+
+func (gs *GameState) HandleChat(msg routing.ChatMessage) {
+	gs.recordChat(msg)
+	fmt.Println()
+	fmt.Println(formatChat(msg))
+}
+
+func (gs *GameState) CommandHistory() {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	if len(gs.chat) == 0 {
+		fmt.Println("No chat messages yet.")
+		return
+	}
+	for _, msg := range gs.chat {
+		fmt.Println(formatChat(msg))
+	}
+}
+
+func (gs *GameState) recordChat(msg routing.ChatMessage) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	gs.chat = append(gs.chat, msg)
+	if len(gs.chat) > chatHistoryLimit {
+		gs.chat = gs.chat[len(gs.chat)-chatHistoryLimit:]
+	}
+}
+
+func formatChat(msg routing.ChatMessage) string {
+	timestamp := msg.SentAt.Format(time.TimeOnly)
+	switch msg.Channel {
+	case routing.ChatDirect:
+		return fmt.Sprintf("[%s] %s -> %s: %s", timestamp, msg.From, msg.To, msg.Message)
+	case routing.ChatAlliance:
+		return fmt.Sprintf("[%s] (allies) %s: %s", timestamp, msg.From, msg.Message)
+	default:
+		return fmt.Sprintf("[%s] %s: %s", timestamp, msg.From, msg.Message)
+	}
+}
@@ -0,0 +1,157 @@
+package gamelogic
+
+import (
+	"math/rand"
+)
+
+const (
+	CombatDeterministic = "deterministic"
+	CombatDice          = "dice"
+)
+
+const diceRounds = 3
+
+type CombatSide int
+
+const (
+	SideNone CombatSide = iota
+	SideAttacker
+	SideDefender
+)
+
+type Combat struct {
+	AttackerPower  int
+	DefenderPower  int
+	Victor         CombatSide
+	AttackerDamage []int
+	DefenderDamage []int
+}
+
+type CombatResolver interface {
+	Resolve(attackers, defenders []Unit, seed int64) Combat
+}
+
+func (s Scenario) Resolver() CombatResolver {
+	switch s.Combat {
+	case CombatDice:
+		return DiceResolver{scenario: s, rounds: diceRounds}
+	default:
+		return DeterministicResolver{scenario: s}
+	}
+}
+
+type DeterministicResolver struct {
+	scenario Scenario
+}
+
+func (r DeterministicResolver) Resolve(attackers, defenders []Unit, seed int64) Combat {
+	combat := Combat{
+		AttackerPower: r.scenario.unitsToPowerLevel(attackers),
+		DefenderPower: r.scenario.unitsToPowerLevel(defenders),
+	}
+	combat.AttackerDamage = r.damage(attackers, combat.AttackerPower, combat.DefenderPower)
+	combat.DefenderDamage = r.damage(defenders, combat.DefenderPower, combat.AttackerPower)
+
+	if combat.AttackerPower > combat.DefenderPower {
+		combat.Victor = SideAttacker
+	} else if combat.DefenderPower > combat.AttackerPower {
+		combat.Victor = SideDefender
+	}
+	return combat
+}
+
+func (r DeterministicResolver) damage(units []Unit, power, enemyPower int) []int {
+	damage := make([]int, len(units))
+	for i, unit := range units {
+		maxHealth := r.scenario.rankHealth(unit.Rank)
+		if power == 0 {
+			damage[i] = maxHealth
+			continue
+		}
+		damage[i] = (maxHealth*enemyPower + power - 1) / power
+	}
+	return damage
+}
+
+type DiceResolver struct {
+	scenario Scenario
+	rounds   int
+}
+
+func (r DiceResolver) Resolve(attackers, defenders []Unit, seed int64) Combat {
+	rng := rand.New(rand.NewSource(seed))
+	combat := Combat{
+		AttackerPower:  r.scenario.unitsToPowerLevel(attackers),
+		DefenderPower:  r.scenario.unitsToPowerLevel(defenders),
+		AttackerDamage: make([]int, len(attackers)),
+		DefenderDamage: make([]int, len(defenders)),
+	}
+
+	for round := 0; round < r.rounds; round++ {
+		attackersAlive := r.alive(attackers, combat.AttackerDamage)
+		defendersAlive := r.alive(defenders, combat.DefenderDamage)
+		if len(attackersAlive) == 0 || len(defendersAlive) == 0 {
+			break
+		}
+		r.volley(rng, attackers, attackersAlive, defenders, defendersAlive, combat.DefenderDamage)
+		r.volley(rng, defenders, defendersAlive, attackers, attackersAlive, combat.AttackerDamage)
+	}
+
+	attackerRemaining := r.remainingPower(attackers, combat.AttackerDamage)
+	defenderRemaining := r.remainingPower(defenders, combat.DefenderDamage)
+	if attackerRemaining > defenderRemaining {
+		combat.Victor = SideAttacker
+	} else if defenderRemaining > attackerRemaining {
+		combat.Victor = SideDefender
+	}
+	return combat
+}
+
+func (r DiceResolver) volley(rng *rand.Rand, shooters []Unit, shooting []int, targets []Unit, targeted []int, damage []int) {
+	for _, i := range shooting {
+		shooter := shooters[i]
+		j := targeted[rng.Intn(len(targeted))]
+		roll := rng.Intn(6) + 1
+		hit := float64(roll*r.scenario.unitPower(shooter)) * r.scenario.counter(shooter.Rank, targets[j].Rank)
+		damage[j] += int(hit)
+	}
+}
+
+func (r DiceResolver) alive(units []Unit, damage []int) []int {
+	alive := []int{}
+	for i, unit := range units {
+		if unitHealth(r.scenario, unit) > damage[i] {
+			alive = append(alive, i)
+		}
+	}
+	return alive
+}
+
+func (r DiceResolver) remainingPower(units []Unit, damage []int) int {
+	survivors := []Unit{}
+	for _, i := range r.alive(units, damage) {
+		unit := units[i]
+		unit.Health = unitHealth(r.scenario, unit) - damage[i]
+		survivors = append(survivors, unit)
+	}
+	return r.scenario.unitsToPowerLevel(survivors)
+}
+
+func (s Scenario) rankPower(rank UnitRank) int {
+	rc, ok := s.rankConfig(rank)
+	if !ok {
+		return 0
+	}
+	return rc.Power
+}
+
+func (s Scenario) counter(attacker, defender UnitRank) float64 {
+	rc, ok := s.rankConfig(attacker)
+	if !ok {
+		return 1
+	}
+	if bonus, ok := rc.Counters[defender]; ok {
+		return bonus
+	}
+	return 1
+}
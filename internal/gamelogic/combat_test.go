@@ -0,0 +1,67 @@
+package gamelogic
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolversAreDeterministic(t *testing.T) {
+	attackers := []Unit{
+		{ID: 1, Rank: RankInfantry},
+		{ID: 2, Rank: RankCavalry, Health: 20},
+		{ID: 3, Rank: RankArtillery, Experience: 2},
+	}
+	defenders := []Unit{
+		{ID: 1, Rank: RankArtillery},
+		{ID: 2, Rank: RankInfantry},
+		{ID: 3, Rank: RankInfantry, Health: 4},
+	}
+
+	tests := []struct {
+		name   string
+		combat string
+		seed   int64
+	}{
+		{"deterministic", CombatDeterministic, 1},
+		{"deterministic ignores other seeds", CombatDeterministic, 42},
+		{"dice", CombatDice, 1},
+		{"dice with another seed", CombatDice, 42},
+		{"dice with a negative seed", CombatDice, -7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := DefaultScenario()
+			s.Combat = tt.combat
+			first := s.Resolver().Resolve(attackers, defenders, tt.seed)
+			for range 10 {
+				again := s.Resolver().Resolve(attackers, defenders, tt.seed)
+				if !reflect.DeepEqual(first, again) {
+					t.Fatalf("Resolve with seed %d gave %+v, then %+v", tt.seed, first, again)
+				}
+			}
+			if len(first.AttackerDamage) != len(attackers) || len(first.DefenderDamage) != len(defenders) {
+				t.Errorf("Resolve returned damage for %d attackers and %d defenders, want %d and %d",
+					len(first.AttackerDamage), len(first.DefenderDamage), len(attackers), len(defenders))
+			}
+		})
+	}
+}
+
+func TestDeterministicResolver(t *testing.T) {
+	s := DefaultScenario()
+	combat := s.Resolver().Resolve(
+		[]Unit{{ID: 1, Rank: RankArtillery}},
+		[]Unit{{ID: 1, Rank: RankInfantry}, {ID: 2, Rank: RankInfantry}},
+		0,
+	)
+	want := Combat{
+		AttackerPower:  10,
+		DefenderPower:  2,
+		Victor:         SideAttacker,
+		AttackerDamage: []int{10},
+		DefenderDamage: []int{50, 50},
+	}
+	if !reflect.DeepEqual(combat, want) {
+		t.Errorf("Resolve() = %+v, want %+v", combat, want)
+	}
+}
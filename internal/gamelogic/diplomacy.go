@@ -0,0 +1,181 @@
+package gamelogic
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+type DiplomacyKind string
+
+const (
+	DiplomacyPropose DiplomacyKind = "propose"
+	DiplomacyAccept  DiplomacyKind = "accept"
+	DiplomacyBreak   DiplomacyKind = "break"
+)
+
+type Diplomacy struct {
+	Kind DiplomacyKind
+	From string
+	To   string
+}
+
+func (gs *GameState) CommandAlly(words []string) (Diplomacy, error) {
+	if len(words) < 2 {
+		return Diplomacy{}, errors.New("usage: ally <username>")
+	}
+	target := words[1]
+	if target == gs.GetUsername() {
+		return Diplomacy{}, errors.New("error: you can not ally with yourself")
+	}
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	if _, ok := gs.allies[target]; ok {
+		return Diplomacy{}, fmt.Errorf("error: you are already allied with %s", target)
+	}
+	gs.proposed[target] = struct{}{}
+
+	fmt.Printf("Proposed an alliance to %s\n", target)
+	return Diplomacy{Kind: DiplomacyPropose, From: gs.Player.Username, To: target}, nil
+}
+
+func (gs *GameState) CommandAccept(words []string) (Diplomacy, error) {
+	if len(words) < 2 {
+		return Diplomacy{}, errors.New("usage: accept <username>")
+	}
+	target := words[1]
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	if _, ok := gs.proposals[target]; !ok {
+		return Diplomacy{}, fmt.Errorf("error: %s has not proposed an alliance", target)
+	}
+	delete(gs.proposals, target)
+	delete(gs.proposed, target)
+	gs.allies[target] = struct{}{}
+
+	fmt.Printf("You are now allied with %s\n", target)
+	return Diplomacy{Kind: DiplomacyAccept, From: gs.Player.Username, To: target}, nil
+}
+
+func (gs *GameState) CommandBreak(words []string) (Diplomacy, error) {
+	if len(words) < 2 {
+		return Diplomacy{}, errors.New("usage: break <username>")
+	}
+	target := words[1]
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	if _, ok := gs.allies[target]; !ok {
+		return Diplomacy{}, fmt.Errorf("error: you are not allied with %s", target)
+	}
+	delete(gs.allies, target)
+
+	fmt.Printf("You broke your alliance with %s\n", target)
+	return Diplomacy{Kind: DiplomacyBreak, From: gs.Player.Username, To: target}, nil
+}
+
+func (gs *GameState) HandleDiplomacy(d Diplomacy) {
+	if d.To != gs.GetUsername() {
+		return
+	}
+
+	defer fmt.Println("------------------------")
+	fmt.Println()
+	fmt.Println("==== Diplomacy ====")
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	switch d.Kind {
+	case DiplomacyPropose:
+		if _, ok := gs.allies[d.From]; ok {
+			fmt.Printf("%s proposed an alliance, but you are already allies.\n", d.From)
+			return
+		}
+		gs.proposals[d.From] = struct{}{}
+		fmt.Printf("%s proposes an alliance. Type \"accept %s\" to accept.\n", d.From, d.From)
+	case DiplomacyAccept:
+		if _, ok := gs.proposed[d.From]; !ok {
+			fmt.Printf("%s accepted an alliance you never proposed.\n", d.From)
+			return
+		}
+		delete(gs.proposed, d.From)
+		delete(gs.proposals, d.From)
+		gs.allies[d.From] = struct{}{}
+		fmt.Printf("%s accepted your alliance!\n", d.From)
+	case DiplomacyBreak:
+		delete(gs.allies, d.From)
+		fmt.Printf("%s broke your alliance!\n", d.From)
+	default:
+		fmt.Printf("Unknown diplomatic message from %s.\n", d.From)
+	}
+}
+
+func (gs *GameState) IsAlly(username string) bool {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	_, ok := gs.allies[username]
+	return ok
+}
+
+func (gs *GameState) GetAllies() []string {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return sortedNames(gs.allies)
+}
+
+func (gs *GameState) recordIntel(p Player) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	gs.intel[p.Username] = p
+}
+
+func (gs *GameState) alliedSupport(locations []Location) []Player {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	support := []Player{}
+	for _, ally := range sortedNames(gs.allies) {
+		p, ok := gs.intel[ally]
+		if !ok {
+			continue
+		}
+		units := map[int]Unit{}
+		for id, unit := range p.Units {
+			if slices.Contains(locations, unit.Location) {
+				units[id] = unit
+			}
+		}
+		if len(units) > 0 {
+			support = append(support, Player{Username: ally, Units: units})
+		}
+	}
+	return support
+}
+
+func (gs *GameState) printDiplomacy() {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	if len(gs.allies) == 0 && len(gs.proposals) == 0 && len(gs.proposed) == 0 {
+		fmt.Println("You have no alliances.")
+		return
+	}
+	for _, ally := range sortedNames(gs.allies) {
+		fmt.Printf("* allied with %s\n", ally)
+	}
+	for _, from := range sortedNames(gs.proposals) {
+		fmt.Printf("* %s proposed an alliance to you\n", from)
+	}
+	for _, to := range sortedNames(gs.proposed) {
+		fmt.Printf("* you proposed an alliance to %s\n", to)
+	}
+}
+
+func sortedNames(names map[string]struct{}) []string {
+	sorted := []string{}
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	slices.Sort(sorted)
+	return sorted
+}
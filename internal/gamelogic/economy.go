@@ -0,0 +1,51 @@
+package gamelogic
+
+import (
+	"fmt"
+	"time"
+)
+
+const defaultIncomeInterval = 10 * time.Second
+
+func (s Scenario) IncomeInterval() time.Duration {
+	if s.IncomeIntervalSeconds <= 0 {
+		return defaultIncomeInterval
+	}
+	return time.Duration(s.IncomeIntervalSeconds) * time.Second
+}
+
+func (s Scenario) Income(p Player) int {
+	occupied := map[Location]struct{}{}
+	for _, unit := range p.Units {
+		occupied[unit.Location] = struct{}{}
+	}
+
+	income := 0
+	for _, loc := range s.Locations {
+		if _, ok := occupied[loc.Name]; ok {
+			income += loc.Income
+		}
+	}
+	return income
+}
+
+func (gs *GameState) CollectIncome() int {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	if gs.Paused {
+		return 0
+	}
+	income := gs.scenario.Income(gs.Player)
+	gs.Player.Resources += income
+	return income
+}
+
+func (gs *GameState) spend(cost int) error {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	if gs.Player.Resources < cost {
+		return fmt.Errorf("error: this costs %d resources but you only have %d", cost, gs.Player.Resources)
+	}
+	gs.Player.Resources -= cost
+	return nil
+}
@@ -1,8 +1,9 @@
 package gamelogic
 
 type Player struct {
-	Username string
-	Units    map[int]Unit
+	Username  string
+	Units     map[int]Unit
+	Resources int
 }
 
 type UnitRank string
@@ -14,39 +15,25 @@ const (
 )
 
 type Unit struct {
-	ID       int
-	Rank     UnitRank
-	Location Location
+	ID         int
+	Rank       UnitRank
+	Location   Location
+	Health     int
+	Experience int
 }
 
 type ArmyMove struct {
 	Player     Player
 	Units      []Unit
 	ToLocation Location
+	Turn       int
 }
 
 type RecognitionOfWar struct {
 	Attacker Player
 	Defender Player
+	Allies   []Player
+	Seed     int64
 }
 
 type Location string
-
-func getAllRanks() map[UnitRank]struct{} {
-	return map[UnitRank]struct{}{
-		RankInfantry:  {},
-		RankCavalry:   {},
-		RankArtillery: {},
-	}
-}
-
-func getAllLocations() map[Location]struct{} {
-	return map[Location]struct{}{
-		"americas":   {},
-		"europe":     {},
-		"africa":     {},
-		"asia":       {},
-		"australia":  {},
-		"antarctica": {},
-	}
-}
@@ -7,6 +7,9 @@ import (
 	"math/rand"
 	"os"
 	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
 )
 
 func PrintClientHelp() {
@@ -17,7 +20,15 @@ func PrintClientHelp() {
 	fmt.Println("* spawn <location> <rank>")
 	fmt.Println("    example:")
 	fmt.Println("    spawn europe infantry")
+	fmt.Println("* ally <username>")
+	fmt.Println("* accept <username>")
+	fmt.Println("* break <username>")
+	fmt.Println("* say <message>")
+	fmt.Println("* whisper <username> <message>")
+	fmt.Println("* allies <message>")
+	fmt.Println("* history")
 	fmt.Println("* status")
+	fmt.Println("* stats [username]")
 	fmt.Println("* spam <n>")
 	fmt.Println("    example:")
 	fmt.Println("    spam 5")
@@ -34,14 +45,36 @@ func ClientWelcome() (string, error) {
 	}
 	username := words[0]
 	fmt.Printf("Welcome, %s!\n", username)
-	PrintClientHelp()
+	PrintLobbyHelp()
 	return username, nil
 }
 
+func PrintLobbyHelp() {
+	fmt.Println("Lobby commands:")
+	fmt.Println("* games")
+	fmt.Println("* create <gameID>")
+	fmt.Println("* join <gameID>")
+	fmt.Println("* match")
+	fmt.Println("* quit")
+	fmt.Println("* help")
+}
+
 func PrintServerHelp() {
 	fmt.Println("Possible commands:")
-	fmt.Println("* pause")
-	fmt.Println("* resume")
+	fmt.Println("* games")
+	fmt.Println("* create <gameID>")
+	fmt.Println("* players [gameID]")
+	fmt.Println("* history [username]")
+	fmt.Println("* leaderboard")
+	fmt.Println("* adduser <username> <password>")
+	fmt.Println("* addkey <username> <hex public key>")
+	fmt.Println("* kick <username>")
+	fmt.Println("* ban <username> [duration]")
+	fmt.Println("* mute <username> [duration]")
+	fmt.Println("* unban <username>")
+	fmt.Println("* bans")
+	fmt.Println("* pause [gameID]")
+	fmt.Println("* resume [gameID]")
 	fmt.Println("* quit")
 	fmt.Println("* help")
 }
@@ -84,9 +117,37 @@ func (gs *GameState) CommandStatus() {
 		fmt.Println("The game is not paused.")
 	}
 
+	if gs.IsTurnBased() {
+		turn, deadline := gs.getTurn()
+		fmt.Printf("It is turn %d, orders are due by %s.\n", turn, deadline.Format(time.TimeOnly))
+	}
+
 	p := gs.GetPlayerSnap()
+	scenario := gs.GetScenario()
 	fmt.Printf("You are %s, and you have %d units.\n", p.Username, len(p.Units))
+	fmt.Printf("Your treasury holds %d resources and your territories yield %d per collection.\n", p.Resources, scenario.Income(p))
 	for _, unit := range p.Units {
-		fmt.Printf("* %v: %v, %v\n", unit.ID, unit.Location, unit.Rank)
+		fmt.Printf("* %v: %v, %v (health %d/%d, veterancy %d, power %d)\n", unit.ID, unit.Location, unit.Rank, unitHealth(scenario, unit), scenario.rankHealth(unit.Rank), veterancy(unit), scenario.unitPower(unit))
+	}
+
+	fmt.Println("Diplomatic relations:")
+	gs.printDiplomacy()
+}
+
+func (gs *GameState) HandleRoster(roster routing.Roster) {
+	defer fmt.Println("------------------------")
+	fmt.Println()
+	fmt.Println("==== Roster ====")
+	if roster.Online {
+		fmt.Printf("%s is now online.\n", roster.Username)
+	} else {
+		fmt.Printf("%s is now offline.\n", roster.Username)
+	}
+	online := []string{}
+	for _, entry := range roster.Players {
+		if entry.Online {
+			online = append(online, entry.Username)
+		}
 	}
+	fmt.Printf("Players online in %s: %s\n", roster.GameID, strings.Join(online, ", "))
 }
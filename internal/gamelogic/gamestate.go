@@ -2,25 +2,73 @@ package gamelogic
 
 import (
 	"sync"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
 )
 
 type GameState struct {
-	Player Player
-	Paused bool
-	mu     *sync.RWMutex
+	GameID   string
+	Player   Player
+	Paused   bool
+	scenario Scenario
+	deployed bool
+	lastUnit int
+
+	turnBased bool
+	turn      int
+	deadline  time.Time
+
+	allies    map[string]struct{}
+	proposals map[string]struct{}
+	proposed  map[string]struct{}
+	intel     map[string]Player
+	wars      map[int64]RecognitionOfWar
+	confirmed map[int64]int
+
+	chat []routing.ChatMessage
+
+	mutedUntil time.Time
+
+	result *routing.GameOver
+
+	mu *sync.RWMutex
 }
 
-func NewGameState(username string) *GameState {
+func NewGameState(gameID, username string) *GameState {
+	scenario := DefaultScenario()
 	return &GameState{
+		GameID: gameID,
 		Player: Player{
-			Username: username,
-			Units:    map[int]Unit{},
+			Username:  username,
+			Units:     map[int]Unit{},
+			Resources: scenario.StartingResources,
 		},
-		Paused: false,
-		mu:     &sync.RWMutex{},
+		Paused:    false,
+		scenario:  scenario,
+		allies:    map[string]struct{}{},
+		proposals: map[string]struct{}{},
+		proposed:  map[string]struct{}{},
+		intel:     map[string]Player{},
+		wars:      map[int64]RecognitionOfWar{},
+		confirmed: map[int64]int{},
+		chat:      []routing.ChatMessage{},
+		mu:        &sync.RWMutex{},
 	}
 }
 
+func (gs *GameState) SetScenario(s Scenario) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	gs.scenario = s
+}
+
+func (gs *GameState) GetScenario() Scenario {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return gs.scenario
+}
+
 func (gs *GameState) resumeGame() {
 	gs.mu.Lock()
 	defer gs.mu.Unlock()
@@ -39,20 +87,25 @@ func (gs *GameState) isPaused() bool {
 	return gs.Paused
 }
 
-func (gs *GameState) addUnit(u Unit) {
+func (gs *GameState) addUnit(rank UnitRank, loc Location) Unit {
 	gs.mu.Lock()
 	defer gs.mu.Unlock()
+	u := Unit{
+		ID:       gs.nextUnitID(),
+		Rank:     rank,
+		Location: loc,
+		Health:   gs.scenario.rankHealth(rank),
+	}
 	gs.Player.Units[u.ID] = u
+	return u
 }
 
-func (gs *GameState) removeUnitsInLocation(loc Location) {
-	gs.mu.Lock()
-	defer gs.mu.Unlock()
-	for k, v := range gs.Player.Units {
-		if v.Location == loc {
-			delete(gs.Player.Units, k)
-		}
+func (gs *GameState) nextUnitID() int {
+	for id := range gs.Player.Units {
+		gs.lastUnit = max(gs.lastUnit, id)
 	}
+	gs.lastUnit++
+	return gs.lastUnit
 }
 
 func (gs *GameState) UpdateUnit(u Unit) {
@@ -61,6 +114,21 @@ func (gs *GameState) UpdateUnit(u Unit) {
 	gs.Player.Units[u.ID] = u
 }
 
+func (gs *GameState) relocateUnit(id int, loc Location) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	u, ok := gs.Player.Units[id]
+	if !ok {
+		return
+	}
+	u.Location = loc
+	gs.Player.Units[id] = u
+}
+
+func (gs *GameState) GetGameID() string {
+	return gs.GameID
+}
+
 func (gs *GameState) GetUsername() string {
 	return gs.Player.Username
 }
@@ -90,7 +158,8 @@ func (gs *GameState) GetPlayerSnap() Player {
 		Units[k] = v
 	}
 	return Player{
-		Username: gs.Player.Username,
-		Units:    Units,
+		Username:  gs.Player.Username,
+		Units:     Units,
+		Resources: gs.Player.Resources,
 	}
 }
@@ -0,0 +1,72 @@
+package gamelogic
+
+import "slices"
+
+const (
+	defaultRankHealth = 10
+	maxVeterancy      = 5
+)
+
+func (s Scenario) rankHealth(rank UnitRank) int {
+	rc, ok := s.rankConfig(rank)
+	if !ok || rc.Health <= 0 {
+		return defaultRankHealth
+	}
+	return rc.Health
+}
+
+func unitHealth(s Scenario, u Unit) int {
+	if u.Health <= 0 {
+		return s.rankHealth(u.Rank)
+	}
+	return u.Health
+}
+
+func veterancy(u Unit) int {
+	return min(u.Experience, maxVeterancy)
+}
+
+func (s Scenario) unitPower(u Unit) int {
+	power := s.rankPower(u.Rank) * (10 + veterancy(u)) * unitHealth(s, u)
+	scale := 10 * s.rankHealth(u.Rank)
+	return (power + scale - 1) / scale
+}
+
+func (s Scenario) ApplyBattle(p Player, br BattleResult) (Player, []int) {
+	units := map[int]Unit{}
+	for id, unit := range p.Units {
+		units[id] = unit
+	}
+
+	killed := []int{}
+	for id, dmg := range br.Damage[p.Username] {
+		unit, ok := units[id]
+		if !ok || dmg <= 0 {
+			continue
+		}
+		unit.Health = unitHealth(s, unit) - dmg
+		if unit.Health <= 0 {
+			delete(units, id)
+			killed = append(killed, id)
+			continue
+		}
+		units[id] = unit
+	}
+	slices.Sort(killed)
+
+	if br.rewards(p.Username) {
+		for id, unit := range units {
+			if unit.Location == br.Location {
+				unit.Experience++
+				units[id] = unit
+			}
+		}
+	}
+
+	p.Units = units
+	return p, killed
+}
+
+func (br BattleResult) rewards(username string) bool {
+	return br.Winner == username || (br.Winner == br.Defender && slices.Contains(br.Allies, username))
+}
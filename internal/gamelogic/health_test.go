@@ -0,0 +1,101 @@
+package gamelogic
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnitPower(t *testing.T) {
+	s := DefaultScenario()
+	tests := []struct {
+		name string
+		unit Unit
+		want int
+	}{
+		{"fresh infantry", Unit{Rank: RankInfantry}, 1},
+		{"wounded infantry keeps its power", Unit{Rank: RankInfantry, Health: 1}, 1},
+		{"fresh cavalry", Unit{Rank: RankCavalry}, 5},
+		{"half health cavalry rounds up", Unit{Rank: RankCavalry, Health: 15, Experience: 1}, 3},
+		{"veteran artillery", Unit{Rank: RankArtillery, Experience: 3}, 13},
+		{"veterancy is capped", Unit{Rank: RankArtillery, Experience: 20}, 15},
+		{"wounded artillery", Unit{Rank: RankArtillery, Health: 26}, 6},
+		{"unknown rank", Unit{Rank: "general"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.unitPower(tt.unit); got != tt.want {
+				t.Errorf("unitPower(%+v) = %d, want %d", tt.unit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyBattle(t *testing.T) {
+	s := DefaultScenario()
+	player := func(username string) Player {
+		return Player{Username: username, Resources: 3, Units: map[int]Unit{
+			1: {ID: 1, Rank: RankInfantry, Location: "europe", Health: 10},
+			2: {ID: 2, Rank: RankCavalry, Location: "europe", Health: 30},
+			3: {ID: 3, Rank: RankInfantry, Location: "asia", Health: 10},
+		}}
+	}
+	br := BattleResult{
+		Location: "europe",
+		Attacker: "alice",
+		Defender: "bob",
+		Allies:   []string{"carol"},
+		Damage: map[string]map[int]int{
+			"alice": {1: 10, 2: 5},
+			"bob":   {2: 40},
+			"carol": {1: 3},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		username   string
+		winner     string
+		wantUnits  map[int]Unit
+		wantKilled []int
+	}{
+		{"attacker wins", "alice", "alice", map[int]Unit{
+			2: {ID: 2, Rank: RankCavalry, Location: "europe", Health: 25, Experience: 1},
+			3: {ID: 3, Rank: RankInfantry, Location: "asia", Health: 10},
+		}, []int{1}},
+		{"defender loses", "bob", "alice", map[int]Unit{
+			1: {ID: 1, Rank: RankInfantry, Location: "europe", Health: 10},
+			3: {ID: 3, Rank: RankInfantry, Location: "asia", Health: 10},
+		}, []int{2}},
+		{"ally of a winning defender", "carol", "bob", map[int]Unit{
+			1: {ID: 1, Rank: RankInfantry, Location: "europe", Health: 7, Experience: 1},
+			2: {ID: 2, Rank: RankCavalry, Location: "europe", Health: 30, Experience: 1},
+			3: {ID: 3, Rank: RankInfantry, Location: "asia", Health: 10},
+		}, []int{}},
+		{"draw gives no experience", "carol", "", map[int]Unit{
+			1: {ID: 1, Rank: RankInfantry, Location: "europe", Health: 7},
+			2: {ID: 2, Rank: RankCavalry, Location: "europe", Health: 30},
+			3: {ID: 3, Rank: RankInfantry, Location: "asia", Health: 10},
+		}, []int{}},
+		{"bystander is untouched", "dave", "alice", player("dave").Units, []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			br := br
+			br.Winner = tt.winner
+			before := player(tt.username)
+			got, killed := s.ApplyBattle(before, br)
+			if !reflect.DeepEqual(got.Units, tt.wantUnits) {
+				t.Errorf("units = %+v, want %+v", got.Units, tt.wantUnits)
+			}
+			if !reflect.DeepEqual(killed, tt.wantKilled) {
+				t.Errorf("killed = %v, want %v", killed, tt.wantKilled)
+			}
+			if got.Resources != before.Resources {
+				t.Errorf("resources changed from %d to %d", before.Resources, got.Resources)
+			}
+			if !reflect.DeepEqual(before, player(tt.username)) {
+				t.Error("ApplyBattle modified the original player")
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package gamelogic
+
+import "fmt"
+
+func (s Scenario) ValidateTransition(prev, next Player, budget int, deploying bool) (int, error) {
+	locations := s.locations()
+	starting := map[StartingUnit]int{}
+	if deploying {
+		for _, su := range s.StartingUnits {
+			starting[su]++
+		}
+	}
+
+	cost := 0
+	for id, unit := range next.Units {
+		if id != unit.ID {
+			return 0, fmt.Errorf("unit %d is filed under ID %d", unit.ID, id)
+		}
+		rc, ok := s.rankConfig(unit.Rank)
+		if !ok {
+			return 0, fmt.Errorf("unit %d has unknown rank %s", id, unit.Rank)
+		}
+		if _, ok := locations[unit.Location]; !ok {
+			return 0, fmt.Errorf("unit %d is in unknown location %s", id, unit.Location)
+		}
+
+		old, ok := prev.Units[id]
+		if ok {
+			if unit.Rank != old.Rank {
+				return 0, fmt.Errorf("unit %d can not change rank from %s to %s", id, old.Rank, unit.Rank)
+			}
+			if unit.Experience > old.Experience {
+				return 0, fmt.Errorf("unit %d gained experience outside of a battle", id)
+			}
+			if unit.Location != old.Location && !s.isAdjacent(old.Location, unit.Location) {
+				return 0, fmt.Errorf("unit %d can not move from %s to %s, it is not adjacent", id, old.Location, unit.Location)
+			}
+			if unitHealth(s, unit) > unitHealth(s, old) {
+				return 0, fmt.Errorf("unit %d healed from %d to %d", id, unitHealth(s, old), unitHealth(s, unit))
+			}
+			continue
+		}
+
+		if unitHealth(s, unit) > s.rankHealth(unit.Rank) || unit.Experience != 0 {
+			return 0, fmt.Errorf("new unit %d must start with at most %d health and no experience", id, s.rankHealth(unit.Rank))
+		}
+		su := StartingUnit{Rank: unit.Rank, Location: unit.Location}
+		if starting[su] > 0 {
+			starting[su]--
+			continue
+		}
+		cost += rc.Cost
+	}
+
+	if cost > budget {
+		return 0, fmt.Errorf("new units cost %d resources but only %d are available", cost, budget)
+	}
+	return cost, nil
+}
@@ -0,0 +1,80 @@
+package gamelogic
+
+import "testing"
+
+func TestValidateTransition(t *testing.T) {
+	s := DefaultScenario()
+	s.StartingUnits = []StartingUnit{{Rank: RankInfantry, Location: "europe"}}
+
+	prev := Player{Username: "alice", Units: map[int]Unit{
+		1: {ID: 1, Rank: RankInfantry, Location: "europe", Health: 10},
+		2: {ID: 2, Rank: RankCavalry, Location: "asia", Health: 20},
+	}}
+	with := func(units ...Unit) Player {
+		p := Player{Username: "alice", Units: map[int]Unit{}}
+		for _, u := range prev.Units {
+			p.Units[u.ID] = u
+		}
+		for _, u := range units {
+			p.Units[u.ID] = u
+		}
+		return p
+	}
+
+	tests := []struct {
+		name      string
+		prev      Player
+		next      Player
+		budget    int
+		deploying bool
+		wantCost  int
+		wantErr   bool
+	}{
+		{"unchanged", prev, with(), 0, false, 0, false},
+		{"adjacent move", prev, with(Unit{ID: 1, Rank: RankInfantry, Location: "africa", Health: 10}), 0, false, 0, false},
+		{"distant move", prev, with(Unit{ID: 1, Rank: RankInfantry, Location: "australia", Health: 10}), 0, false, 0, true},
+		{"unit lost in battle", prev, Player{Username: "alice", Units: map[int]Unit{2: prev.Units[2]}}, 0, false, 0, false},
+		{"wounded unit", prev, with(Unit{ID: 2, Rank: RankCavalry, Location: "asia", Health: 5}), 0, false, 0, false},
+		{"healed unit", prev, with(Unit{ID: 2, Rank: RankCavalry, Location: "asia", Health: 30}), 0, false, 0, true},
+		{"promoted unit", prev, with(Unit{ID: 1, Rank: RankArtillery, Location: "europe"}), 8, false, 0, true},
+		{"demoted unit", prev, with(Unit{ID: 2, Rank: RankInfantry, Location: "asia", Health: 10}), 8, false, 0, true},
+		{"experience without a battle", prev, with(Unit{ID: 1, Rank: RankInfantry, Location: "europe", Health: 10, Experience: 1}), 0, false, 0, true},
+		{"veteran keeps its experience", with(Unit{ID: 1, Rank: RankInfantry, Location: "europe", Health: 10, Experience: 2}), with(Unit{ID: 1, Rank: RankInfantry, Location: "africa", Health: 10, Experience: 2}), 0, false, 0, false},
+		{"spawn within budget", prev, with(Unit{ID: 3, Rank: RankCavalry, Location: "asia"}), 4, false, 4, false},
+		{"spawn over budget", prev, with(Unit{ID: 3, Rank: RankArtillery, Location: "asia"}), 7, false, 0, true},
+		{"veteran spawn", prev, with(Unit{ID: 3, Rank: RankInfantry, Location: "asia", Experience: 2}), 10, false, 0, true},
+		{"unknown location", prev, with(Unit{ID: 3, Rank: RankInfantry, Location: "mars"}), 10, false, 0, true},
+		{"mismatched ID", prev, Player{Units: map[int]Unit{3: {ID: 4, Rank: RankInfantry, Location: "asia"}}}, 10, false, 0, true},
+		{"starting units are free", Player{}, Player{Units: map[int]Unit{1: {ID: 1, Rank: RankInfantry, Location: "europe"}}}, 0, true, 0, false},
+		{"starting units are free once", Player{}, Player{Units: map[int]Unit{
+			1: {ID: 1, Rank: RankInfantry, Location: "europe"},
+			2: {ID: 2, Rank: RankInfantry, Location: "europe"},
+		}}, 1, true, 1, false},
+		{"starting units cost after deploying", Player{}, Player{Units: map[int]Unit{1: {ID: 1, Rank: RankInfantry, Location: "europe"}}}, 0, false, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cost, err := s.ValidateTransition(tt.prev, tt.next, tt.budget, tt.deploying)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateTransition() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if cost != tt.wantCost {
+				t.Errorf("ValidateTransition() cost = %d, want %d", cost, tt.wantCost)
+			}
+		})
+	}
+}
+
+func TestUnitIDsAreNotReused(t *testing.T) {
+	gs := NewGameState("test", "alice")
+	first := gs.addUnit(RankInfantry, "europe")
+	second := gs.addUnit(RankInfantry, "europe")
+	gs.mu.Lock()
+	delete(gs.Player.Units, second.ID)
+	gs.mu.Unlock()
+
+	third := gs.addUnit(RankInfantry, "europe")
+	if third.ID == second.ID || third.ID == first.ID {
+		t.Errorf("new unit reused ID %d", third.ID)
+	}
+}
@@ -0,0 +1,51 @@
+package gamelogic
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+func (gs *GameState) HandleModeration(m routing.Moderation) {
+	defer fmt.Println("------------------------")
+	fmt.Println()
+	switch m.Action {
+	case routing.ModerationWarn:
+		fmt.Println("==== Warning ====")
+		fmt.Printf("The server warned you: %s\n", m.Reason)
+	case routing.ModerationMute:
+		fmt.Println("==== Muted ====")
+		fmt.Printf("You have been muted until %s: %s\n", m.Until.Format(time.TimeOnly), m.Reason)
+		gs.mu.Lock()
+		gs.mutedUntil = m.Until
+		gs.mu.Unlock()
+	case routing.ModerationUnmute:
+		fmt.Println("==== Unmuted ====")
+		fmt.Printf("You can play again: %s\n", m.Reason)
+		gs.mu.Lock()
+		gs.mutedUntil = time.Time{}
+		gs.mu.Unlock()
+	case routing.ModerationKick:
+		fmt.Println("==== Kicked ====")
+		fmt.Printf("You have been removed from the server: %s\n", m.Reason)
+	case routing.ModerationBan:
+		fmt.Println("==== Banned ====")
+		if m.Until.IsZero() {
+			fmt.Printf("You have been banned permanently: %s\n", m.Reason)
+		} else {
+			fmt.Printf("You have been banned until %s: %s\n", m.Until.Format(time.DateTime), m.Reason)
+		}
+	default:
+		fmt.Printf("Unknown moderation action %s\n", m.Action)
+	}
+}
+
+func (gs *GameState) mutedFor() time.Time {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	if time.Now().After(gs.mutedUntil) {
+		return time.Time{}
+	}
+	return gs.mutedUntil
+}
@@ -3,7 +3,9 @@ package gamelogic
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"strconv"
+	"time"
 )
 
 type MoveOutcome int
@@ -29,35 +31,65 @@ func (gs *GameState) HandleMove(move ArmyMove) MoveOutcome {
 		return MoveOutcomeSamePlayer
 	}
 
-	overlappingLocation := getOverlappingLocation(player, move.Player)
-	if overlappingLocation != "" {
-		fmt.Printf("You have units in %s! You are at war with %s!\n", overlappingLocation, move.Player.Username)
+	gs.recordIntel(move.Player)
+	if gs.IsAlly(move.Player.Username) {
+		fmt.Printf("%s is your ally, their units are welcome.\n", move.Player.Username)
+		return MoveOutcomeSafe
+	}
+
+	overlappingLocations := getOverlappingLocations(player, move.Player)
+	if len(overlappingLocations) > 0 {
+		for _, loc := range overlappingLocations {
+			fmt.Printf("You have units in %s! You are at war with %s!\n", loc, move.Player.Username)
+		}
 		return MoveOutcomeMakeWar
 	}
 	fmt.Printf("You are safe from %s's units.\n", move.Player.Username)
 	return MoveOutcomeSafe
 }
 
-func getOverlappingLocation(p1 Player, p2 Player) Location {
+func getOverlappingLocations(p1 Player, p2 Player) []Location {
+	occupied := map[Location]struct{}{}
 	for _, u1 := range p1.Units {
-		for _, u2 := range p2.Units {
-			if u1.Location == u2.Location {
-				return u1.Location
-			}
+		occupied[u1.Location] = struct{}{}
+	}
+
+	seen := map[Location]struct{}{}
+	locations := []Location{}
+	for _, u2 := range p2.Units {
+		if _, ok := occupied[u2.Location]; !ok {
+			continue
 		}
+		if _, ok := seen[u2.Location]; ok {
+			continue
+		}
+		seen[u2.Location] = struct{}{}
+		locations = append(locations, u2.Location)
 	}
-	return ""
+	slices.Sort(locations)
+	return locations
 }
 
 func (gs *GameState) CommandMove(words []string) (ArmyMove, error) {
+	if gs.isOver() {
+		return ArmyMove{}, errors.New("the game is over, you can not move units")
+	}
 	if gs.isPaused() {
 		return ArmyMove{}, errors.New("the game is paused, you can not move units")
 	}
+	if until := gs.mutedFor(); !until.IsZero() {
+		return ArmyMove{}, fmt.Errorf("you are muted until %s", until.Format(time.TimeOnly))
+	}
 	if len(words) < 3 {
 		return ArmyMove{}, errors.New("usage: move <location> <unitID> <unitID> <unitID> etc")
 	}
+	turnBased := gs.IsTurnBased()
+	turn, deadline := gs.getTurn()
+	if turnBased && time.Now().After(deadline) {
+		return ArmyMove{}, fmt.Errorf("the deadline for turn %d has passed, wait for the next turn", turn)
+	}
 	newLocation := Location(words[1])
-	locations := getAllLocations()
+	locations := gs.GetScenario().locations()
 	if _, ok := locations[newLocation]; !ok {
 		return ArmyMove{}, fmt.Errorf("error: %s is not a valid location", newLocation)
 	}
@@ -71,21 +103,40 @@ func (gs *GameState) CommandMove(words []string) (ArmyMove, error) {
 		unitIDs = append(unitIDs, unitID)
 	}
 
+	scenario := gs.GetScenario()
 	newUnits := []Unit{}
 	for _, unitID := range unitIDs {
 		unit, ok := gs.GetUnit(unitID)
 		if !ok {
 			return ArmyMove{}, fmt.Errorf("error: unit with ID %v not found", unitID)
 		}
+		if unit.Location != newLocation && !scenario.isAdjacent(unit.Location, newLocation) {
+			return ArmyMove{}, fmt.Errorf("error: unit %v in %s can not reach %s, it is not adjacent", unitID, unit.Location, newLocation)
+		}
 		unit.Location = newLocation
-		gs.UpdateUnit(unit)
 		newUnits = append(newUnits, unit)
 	}
 
+	if !turnBased {
+		for _, unit := range newUnits {
+			gs.UpdateUnit(unit)
+		}
+	}
+
+	player := gs.GetPlayerSnap()
+	for _, unit := range newUnits {
+		player.Units[unit.ID] = unit
+	}
+
 	mv := ArmyMove{
 		ToLocation: newLocation,
 		Units:      newUnits,
-		Player:     gs.GetPlayerSnap(),
+		Player:     player,
+		Turn:       turn,
+	}
+	if turnBased {
+		fmt.Printf("Ordered %v units to move to %s at the end of turn %d\n", len(mv.Units), mv.ToLocation, turn)
+		return mv, nil
 	}
 	fmt.Printf("Moved %v units to %s\n", len(mv.Units), mv.ToLocation)
 	return mv, nil
@@ -0,0 +1,225 @@
+package gamelogic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+type Scenario struct {
+	Name                  string            `json:"name"`
+	Locations             []LocationConfig  `json:"locations"`
+	Ranks                 []RankConfig      `json:"ranks"`
+	StartingUnits         []StartingUnit    `json:"starting_units"`
+	StartingResources     int               `json:"starting_resources"`
+	IncomeIntervalSeconds int               `json:"income_interval_seconds,omitempty"`
+	Combat                string            `json:"combat,omitempty"`
+	Victory               VictoryConditions `json:"victory"`
+}
+
+type LocationConfig struct {
+	Name     Location   `json:"name"`
+	Adjacent []Location `json:"adjacent"`
+	Income   int        `json:"income"`
+}
+
+type RankConfig struct {
+	Name     UnitRank             `json:"name"`
+	Power    int                  `json:"power"`
+	Cost     int                  `json:"cost"`
+	Health   int                  `json:"health,omitempty"`
+	Counters map[UnitRank]float64 `json:"counters,omitempty"`
+}
+
+type StartingUnit struct {
+	Rank     UnitRank `json:"rank"`
+	Location Location `json:"location"`
+}
+
+type VictoryConditions struct {
+	ControlLocations   int  `json:"control_locations,omitempty"`
+	EliminateOpponents bool `json:"eliminate_opponents,omitempty"`
+	TimeLimitSeconds   int  `json:"time_limit_seconds,omitempty"`
+}
+
+func DefaultScenario() Scenario {
+	return Scenario{
+		Name: "classic",
+		Locations: []LocationConfig{
+			{Name: "americas", Adjacent: []Location{"europe", "asia", "antarctica"}, Income: 3},
+			{Name: "europe", Adjacent: []Location{"americas", "africa", "asia"}, Income: 3},
+			{Name: "africa", Adjacent: []Location{"europe", "asia", "antarctica"}, Income: 2},
+			{Name: "asia", Adjacent: []Location{"americas", "europe", "africa", "australia"}, Income: 3},
+			{Name: "australia", Adjacent: []Location{"asia", "antarctica"}, Income: 2},
+			{Name: "antarctica", Adjacent: []Location{"americas", "africa", "australia"}, Income: 1},
+		},
+		Ranks: []RankConfig{
+			{Name: RankInfantry, Power: 1, Cost: 1, Health: 10},
+			{Name: RankCavalry, Power: 5, Cost: 4, Health: 30, Counters: map[UnitRank]float64{RankArtillery: 2}},
+			{Name: RankArtillery, Power: 10, Cost: 8, Health: 50},
+		},
+		StartingUnits:         []StartingUnit{},
+		StartingResources:     20,
+		IncomeIntervalSeconds: 10,
+		Combat:                CombatDeterministic,
+		Victory:               VictoryConditions{},
+	}
+}
+
+func LoadScenario(path string) (Scenario, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		err := fmt.Errorf("could not open scenario file: %w", err)
+		return Scenario{}, err
+	}
+	defer f.Close()
+
+	var s Scenario
+	decoder := json.NewDecoder(f)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&s); err != nil {
+		err := fmt.Errorf("could not decode scenario file: %w", err)
+		return Scenario{}, err
+	}
+
+	if err := s.Validate(); err != nil {
+		err := fmt.Errorf("invalid scenario %s: %w", path, err)
+		return Scenario{}, err
+	}
+
+	return s, nil
+}
+
+func (s Scenario) Validate() error {
+	if len(s.Locations) == 0 {
+		return errors.New("scenario must define at least one location")
+	}
+	if len(s.Ranks) == 0 {
+		return errors.New("scenario must define at least one unit rank")
+	}
+
+	locations := s.locations()
+	if len(locations) != len(s.Locations) {
+		return errors.New("location names must be unique")
+	}
+	for _, loc := range s.Locations {
+		if loc.Name == "" {
+			return errors.New("location name must not be empty")
+		}
+		if loc.Income < 0 {
+			return fmt.Errorf("location %s must not have a negative income", loc.Name)
+		}
+		for _, adj := range loc.Adjacent {
+			if _, ok := locations[adj]; !ok {
+				return fmt.Errorf("location %s is adjacent to unknown location %s", loc.Name, adj)
+			}
+		}
+	}
+
+	ranks := s.ranks()
+	if len(ranks) != len(s.Ranks) {
+		return errors.New("rank names must be unique")
+	}
+	for _, rank := range s.Ranks {
+		if rank.Name == "" {
+			return errors.New("rank name must not be empty")
+		}
+		if rank.Power < 0 || rank.Cost < 0 || rank.Health < 0 {
+			return fmt.Errorf("rank %s must not have a negative power, cost or health", rank.Name)
+		}
+	}
+	for _, rank := range s.Ranks {
+		for target, bonus := range rank.Counters {
+			if _, ok := ranks[target]; !ok {
+				return fmt.Errorf("rank %s counters unknown rank %s", rank.Name, target)
+			}
+			if bonus < 0 {
+				return fmt.Errorf("rank %s must not have a negative bonus against %s", rank.Name, target)
+			}
+		}
+	}
+
+	switch s.Combat {
+	case "", CombatDeterministic, CombatDice:
+	default:
+		return fmt.Errorf("unknown combat resolver %s", s.Combat)
+	}
+
+	for _, su := range s.StartingUnits {
+		if _, ok := locations[su.Location]; !ok {
+			return fmt.Errorf("starting unit is in unknown location %s", su.Location)
+		}
+		if _, ok := ranks[su.Rank]; !ok {
+			return fmt.Errorf("starting unit has unknown rank %s", su.Rank)
+		}
+	}
+
+	if s.StartingResources < 0 || s.IncomeIntervalSeconds < 0 {
+		return errors.New("starting resources and income interval must not be negative")
+	}
+
+	if s.Victory.ControlLocations < 0 || s.Victory.ControlLocations > len(s.Locations) {
+		return fmt.Errorf("victory condition requires controlling %d locations but the map has %d", s.Victory.ControlLocations, len(s.Locations))
+	}
+	if s.Victory.TimeLimitSeconds < 0 {
+		return errors.New("victory time limit must not be negative")
+	}
+
+	return nil
+}
+
+func (s Scenario) locations() map[Location]struct{} {
+	locations := map[Location]struct{}{}
+	for _, loc := range s.Locations {
+		locations[loc.Name] = struct{}{}
+	}
+	return locations
+}
+
+func (s Scenario) ranks() map[UnitRank]struct{} {
+	ranks := map[UnitRank]struct{}{}
+	for _, rank := range s.Ranks {
+		ranks[rank.Name] = struct{}{}
+	}
+	return ranks
+}
+
+func (s Scenario) rankConfig(rank UnitRank) (RankConfig, bool) {
+	for _, rc := range s.Ranks {
+		if rc.Name == rank {
+			return rc, true
+		}
+	}
+	return RankConfig{}, false
+}
+
+func (gs *GameState) HandleScenario(s Scenario) {
+	defer fmt.Println("------------------------")
+	fmt.Println()
+	fmt.Println("==== Scenario Received ====")
+	fmt.Printf("Playing scenario %s with %d locations and %d ranks.\n", s.Name, len(s.Locations), len(s.Ranks))
+	gs.SetScenario(s)
+	gs.deployStartingUnits()
+}
+
+func (gs *GameState) deployStartingUnits() {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	if gs.deployed {
+		return
+	}
+	gs.deployed = true
+	gs.Player.Resources = gs.scenario.StartingResources
+
+	for _, su := range gs.scenario.StartingUnits {
+		id := gs.nextUnitID()
+		gs.Player.Units[id] = Unit{
+			ID:       id,
+			Rank:     su.Rank,
+			Location: su.Location,
+			Health:   gs.scenario.rankHealth(su.Rank),
+		}
+		fmt.Printf("Deployed a(n) %s in %s with id %v\n", su.Rank, su.Location, id)
+	}
+}
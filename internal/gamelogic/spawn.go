@@ -6,29 +6,33 @@ import (
 )
 
 func (gs *GameState) CommandSpawn(words []string) error {
+	if gs.isOver() {
+		return errors.New("the game is over, you can not spawn units")
+	}
 	if len(words) < 3 {
 		return errors.New("usage: spawn <location> <rank>")
 	}
 
+	scenario := gs.GetScenario()
+
 	locationName := words[1]
-	locations := getAllLocations()
+	locations := scenario.locations()
 	if _, ok := locations[Location(locationName)]; !ok {
 		return fmt.Errorf("error: %s is not a valid location", locationName)
 	}
 
 	rank := words[2]
-	units := getAllRanks()
-	if _, ok := units[UnitRank(rank)]; !ok {
+	rc, ok := scenario.rankConfig(UnitRank(rank))
+	if !ok {
 		return fmt.Errorf("error: %s is not a valid unit", rank)
 	}
 
-	id := len(gs.getUnitsSnap()) + 1
-	gs.addUnit(Unit{
-		ID:       id,
-		Rank:     UnitRank(rank),
-		Location: Location(locationName),
-	})
+	if err := gs.spend(rc.Cost); err != nil {
+		return err
+	}
+
+	unit := gs.addUnit(UnitRank(rank), Location(locationName))
 
-	fmt.Printf("Spawned a(n) %s in %s with id %v\n", rank, locationName, id)
+	fmt.Printf("Spawned a(n) %s in %s with id %v for %d resources\n", rank, locationName, unit.ID, rc.Cost)
 	return nil
 }
@@ -0,0 +1,30 @@
+package gamelogic
+
+import (
+	"fmt"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+func PrintStats(stats routing.PlayerStats) {
+	defer fmt.Println("------------------------")
+	fmt.Println()
+	fmt.Printf("==== Stats for %s ====\n", stats.Username)
+	fmt.Printf("Rating: %d\n", stats.Rating)
+	fmt.Printf("Battles won: %d, lost: %d, drawn: %d\n", stats.Wins, stats.Losses, stats.Draws)
+	fmt.Printf("Units destroyed: %d, units lost: %d\n", stats.UnitsDestroyed, stats.UnitsLost)
+	fmt.Printf("Territories held: %d\n", stats.Territories)
+}
+
+func PrintLeaderboard(leaderboard []routing.PlayerStats) {
+	defer fmt.Println("------------------------")
+	fmt.Println()
+	fmt.Println("==== Leaderboard ====")
+	if len(leaderboard) == 0 {
+		fmt.Println("No battles have been fought yet.")
+		return
+	}
+	for i, stats := range leaderboard {
+		fmt.Printf("%d. %s (%d): %d won, %d lost, %d drawn, %d destroyed, %d territories\n", i+1, stats.Username, stats.Rating, stats.Wins, stats.Losses, stats.Draws, stats.UnitsDestroyed, stats.Territories)
+	}
+}
@@ -0,0 +1,71 @@
+package gamelogic
+
+import (
+	"fmt"
+	"time"
+)
+
+type Turn struct {
+	Number   int
+	Deadline time.Time
+	IsPaused bool
+	Resolved []ArmyMove
+}
+
+func (gs *GameState) HandleTurn(t Turn) []ArmyMove {
+	defer fmt.Println("------------------------")
+	fmt.Println()
+	fmt.Printf("==== Turn %d ====\n", t.Number)
+
+	gs.mu.Lock()
+	newTurn := t.Number > gs.turn
+	gs.turnBased = true
+	gs.turn = t.Number
+	gs.deadline = t.Deadline
+	gs.mu.Unlock()
+
+	username := gs.GetUsername()
+	for _, move := range t.Resolved {
+		if move.Player.Username != username {
+			continue
+		}
+		for _, unit := range move.Units {
+			gs.relocateUnit(unit.ID, move.ToLocation)
+		}
+		fmt.Printf("Your order moving %v unit(s) to %s was carried out.\n", len(move.Units), move.ToLocation)
+	}
+
+	hostile := []ArmyMove{}
+	for _, move := range t.Resolved {
+		if move.Player.Username == username {
+			continue
+		}
+		if gs.HandleMove(move) == MoveOutcomeMakeWar {
+			hostile = append(hostile, move)
+		}
+	}
+
+	if newTurn {
+		income := gs.CollectIncome()
+		fmt.Printf("You collected %d resources.\n", income)
+	}
+
+	if t.IsPaused {
+		fmt.Println("The turn clock is paused.")
+	} else {
+		fmt.Printf("Submit your orders before %s.\n", t.Deadline.Format(time.TimeOnly))
+	}
+	return hostile
+}
+
+func (gs *GameState) IsTurnBased() bool {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return gs.turnBased
+}
+
+func (gs *GameState) getTurn() (int, time.Time) {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return gs.turn, gs.deadline
+}
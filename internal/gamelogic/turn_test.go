@@ -0,0 +1,45 @@
+package gamelogic
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHandleTurnRelocatesOwnUnits(t *testing.T) {
+	order := Unit{ID: 1, Rank: RankCavalry, Location: "europe", Health: 30}
+	tests := []struct {
+		name    string
+		current map[int]Unit
+		want    map[int]Unit
+	}{
+		{"unchanged unit moves", map[int]Unit{
+			1: order,
+		}, map[int]Unit{
+			1: {ID: 1, Rank: RankCavalry, Location: "asia", Health: 30},
+		}},
+		{"wounded veteran keeps its state", map[int]Unit{
+			1: {ID: 1, Rank: RankCavalry, Location: "europe", Health: 12, Experience: 2},
+		}, map[int]Unit{
+			1: {ID: 1, Rank: RankCavalry, Location: "asia", Health: 12, Experience: 2},
+		}},
+		{"unit lost since the order stays lost", map[int]Unit{
+			2: {ID: 2, Rank: RankInfantry, Location: "africa", Health: 10},
+		}, map[int]Unit{
+			2: {ID: 2, Rank: RankInfantry, Location: "africa", Health: 10},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := NewGameState("test", "alice")
+			gs.Player.Units = tt.current
+			gs.HandleTurn(Turn{Number: 1, Resolved: []ArmyMove{{
+				Player:     Player{Username: "alice"},
+				Units:      []Unit{order},
+				ToLocation: "asia",
+			}}})
+			if !reflect.DeepEqual(gs.Player.Units, tt.want) {
+				t.Errorf("units = %+v, want %+v", gs.Player.Units, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package gamelogic
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+func (s Scenario) TimeLimit() time.Duration {
+	return time.Duration(s.Victory.TimeLimitSeconds) * time.Second
+}
+
+func (s Scenario) Score(p Player) int {
+	units := []Unit{}
+	for _, unit := range p.Units {
+		units = append(units, unit)
+	}
+	return s.Income(p) + s.unitsToPowerLevel(units)
+}
+
+func controlled(players map[string]Player) map[string]int {
+	holders := map[Location]map[string]struct{}{}
+	for username, p := range players {
+		for _, unit := range p.Units {
+			if holders[unit.Location] == nil {
+				holders[unit.Location] = map[string]struct{}{}
+			}
+			holders[unit.Location][username] = struct{}{}
+		}
+	}
+
+	counts := map[string]int{}
+	for _, held := range holders {
+		if len(held) != 1 {
+			continue
+		}
+		for username := range held {
+			counts[username]++
+		}
+	}
+	return counts
+}
+
+func (s Scenario) CheckVictory(players map[string]Player, contenders []string, elapsed time.Duration) (routing.GameOver, bool) {
+	over := routing.GameOver{Scores: map[string]int{}}
+	for username, p := range players {
+		over.Scores[username] = s.Score(p)
+	}
+
+	if n := s.Victory.ControlLocations; n > 0 {
+		counts := controlled(players)
+		leaders := []string{}
+		for username, count := range counts {
+			if count >= n {
+				leaders = append(leaders, username)
+			}
+		}
+		slices.SortFunc(leaders, func(a, b string) int {
+			return cmp.Or(cmp.Compare(counts[b], counts[a]), strings.Compare(a, b))
+		})
+		if len(leaders) > 0 {
+			over.Winner = leaders[0]
+			over.Reason = fmt.Sprintf("%s controls %d locations", over.Winner, counts[over.Winner])
+			return over, true
+		}
+	}
+
+	if s.Victory.EliminateOpponents && len(contenders) > 1 {
+		alive := []string{}
+		for _, username := range contenders {
+			if len(players[username].Units) > 0 {
+				alive = append(alive, username)
+			}
+		}
+		switch len(alive) {
+		case 0:
+			over.Reason = "every army has been destroyed"
+			return over, true
+		case 1:
+			over.Winner = alive[0]
+			over.Reason = fmt.Sprintf("%s eliminated every opponent", over.Winner)
+			return over, true
+		}
+	}
+
+	if limit := s.TimeLimit(); limit > 0 && elapsed >= limit {
+		leaders := []string{}
+		for username, score := range over.Scores {
+			switch {
+			case len(leaders) == 0 || score > over.Scores[leaders[0]]:
+				leaders = []string{username}
+			case score == over.Scores[leaders[0]]:
+				leaders = append(leaders, username)
+			}
+		}
+		switch len(leaders) {
+		case 0:
+			over.Reason = fmt.Sprintf("the time limit of %s ran out with no players in the game", limit)
+		case 1:
+			over.Winner = leaders[0]
+			over.Reason = fmt.Sprintf("%s had the highest score when the time limit of %s ran out", over.Winner, limit)
+		default:
+			over.Reason = fmt.Sprintf("the time limit of %s ran out with the top scores tied", limit)
+		}
+		return over, true
+	}
+
+	return routing.GameOver{}, false
+}
+
+func (gs *GameState) HandleGameOver(over routing.GameOver) {
+	defer fmt.Println("------------------------")
+	gs.mu.Lock()
+	gs.result = &over
+	gs.mu.Unlock()
+
+	fmt.Println()
+	fmt.Println("==== Game Over ====")
+	switch over.Winner {
+	case "":
+		fmt.Println("The game ended in a draw.")
+	case gs.GetUsername():
+		fmt.Println("Victory! You won the game.")
+	default:
+		fmt.Printf("Defeat. %s won the game.\n", over.Winner)
+	}
+	fmt.Printf("Reason: %s\n", over.Reason)
+
+	usernames := []string{}
+	for username := range over.Scores {
+		usernames = append(usernames, username)
+	}
+	slices.SortFunc(usernames, func(a, b string) int {
+		return cmp.Or(cmp.Compare(over.Scores[b], over.Scores[a]), strings.Compare(a, b))
+	})
+	fmt.Println("Final scores:")
+	for i, username := range usernames {
+		fmt.Printf("%d. %s: %d\n", i+1, username, over.Scores[username])
+	}
+	fmt.Println("No further moves are possible, type quit to leave.")
+}
+
+func (gs *GameState) isOver() bool {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return gs.result != nil
+}
@@ -0,0 +1,113 @@
+package gamelogic
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckVictory(t *testing.T) {
+	army := func(locations ...Location) Player {
+		p := Player{Units: map[int]Unit{}}
+		for i, loc := range locations {
+			p.Units[i+1] = Unit{ID: i + 1, Rank: RankInfantry, Location: loc}
+		}
+		return p
+	}
+
+	tests := []struct {
+		name       string
+		victory    VictoryConditions
+		players    map[string]Player
+		contenders []string
+		elapsed    time.Duration
+		wantOver   bool
+		wantWinner string
+	}{
+		{
+			name:     "no conditions",
+			players:  map[string]Player{"alice": army("europe")},
+			elapsed:  time.Hour,
+			wantOver: false,
+		},
+		{
+			name:     "control locations",
+			victory:  VictoryConditions{ControlLocations: 2},
+			players:  map[string]Player{"alice": army("europe", "asia"), "bob": army("asia")},
+			wantOver: false,
+		},
+		{
+			name:       "control locations reached",
+			victory:    VictoryConditions{ControlLocations: 2},
+			players:    map[string]Player{"alice": army("europe", "africa"), "bob": army("asia")},
+			wantOver:   true,
+			wantWinner: "alice",
+		},
+		{
+			name:       "last army standing",
+			victory:    VictoryConditions{EliminateOpponents: true},
+			players:    map[string]Player{"alice": army("europe"), "bob": army()},
+			contenders: []string{"alice", "bob"},
+			wantOver:   true,
+			wantWinner: "alice",
+		},
+		{
+			name:       "a lone player has no opponents to eliminate",
+			victory:    VictoryConditions{EliminateOpponents: true},
+			players:    map[string]Player{"alice": army("europe")},
+			contenders: []string{"alice"},
+			wantOver:   false,
+		},
+		{
+			name:       "every army destroyed",
+			victory:    VictoryConditions{EliminateOpponents: true},
+			players:    map[string]Player{"alice": army(), "bob": army()},
+			contenders: []string{"alice", "bob"},
+			wantOver:   true,
+		},
+		{
+			name:     "time limit not reached",
+			victory:  VictoryConditions{TimeLimitSeconds: 60},
+			players:  map[string]Player{"alice": army("europe")},
+			elapsed:  59 * time.Second,
+			wantOver: false,
+		},
+		{
+			name:       "highest score at the time limit",
+			victory:    VictoryConditions{TimeLimitSeconds: 60},
+			players:    map[string]Player{"alice": army("europe", "asia"), "bob": army("africa")},
+			elapsed:    time.Minute,
+			wantOver:   true,
+			wantWinner: "alice",
+		},
+		{
+			name:     "tied scores at the time limit",
+			victory:  VictoryConditions{TimeLimitSeconds: 60},
+			players:  map[string]Player{"alice": army("europe"), "bob": army("asia")},
+			elapsed:  time.Minute,
+			wantOver: true,
+		},
+		{
+			name:     "no players at the time limit",
+			victory:  VictoryConditions{TimeLimitSeconds: 60},
+			players:  map[string]Player{},
+			elapsed:  time.Minute,
+			wantOver: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := DefaultScenario()
+			s.Victory = tt.victory
+			over, ok := s.CheckVictory(tt.players, tt.contenders, tt.elapsed)
+			if ok != tt.wantOver {
+				t.Fatalf("CheckVictory() over = %v, want %v", ok, tt.wantOver)
+			}
+			if over.Winner != tt.wantWinner {
+				t.Errorf("CheckVictory() winner = %q, want %q", over.Winner, tt.wantWinner)
+			}
+			if ok && over.Reason == "" {
+				t.Error("CheckVictory() ended the game without a reason")
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package gamelogic
+
+func (s Scenario) isAdjacent(a, b Location) bool {
+	for _, loc := range s.Locations {
+		if loc.Name != a && loc.Name != b {
+			continue
+		}
+		for _, adj := range loc.Adjacent {
+			if (loc.Name == a && adj == b) || (loc.Name == b && adj == a) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s Scenario) visibleLocations(observer Player) map[Location]struct{} {
+	occupied := map[Location]struct{}{}
+	for _, unit := range observer.Units {
+		occupied[unit.Location] = struct{}{}
+	}
+	visible := map[Location]struct{}{}
+	for _, loc := range s.Locations {
+		if _, ok := occupied[loc.Name]; ok {
+			visible[loc.Name] = struct{}{}
+			continue
+		}
+		for held := range occupied {
+			if s.isAdjacent(loc.Name, held) {
+				visible[loc.Name] = struct{}{}
+				break
+			}
+		}
+	}
+	return visible
+}
+
+func (s Scenario) RedactPlayer(p Player, observer Player) Player {
+	visible := s.visibleLocations(observer)
+	units := map[int]Unit{}
+	for id, unit := range p.Units {
+		if _, ok := visible[unit.Location]; ok {
+			units[id] = unit
+		}
+	}
+	redacted := Player{
+		Username: p.Username,
+		Units:    units,
+	}
+	if p.Username == observer.Username {
+		redacted.Resources = p.Resources
+	}
+	return redacted
+}
+
+func (s Scenario) RedactMove(move ArmyMove, observer Player) (ArmyMove, bool) {
+	player := s.RedactPlayer(move.Player, observer)
+	units := []Unit{}
+	if _, ok := s.visibleLocations(observer)[move.ToLocation]; ok {
+		units = move.Units
+	}
+	if len(units) == 0 && len(player.Units) == 0 {
+		return ArmyMove{}, false
+	}
+	return ArmyMove{
+		Player:     player,
+		Units:      units,
+		ToLocation: move.ToLocation,
+		Turn:       move.Turn,
+	}, true
+}
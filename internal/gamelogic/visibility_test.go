@@ -0,0 +1,47 @@
+package gamelogic
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestRedactPlayer(t *testing.T) {
+	s := DefaultScenario()
+	alice := Player{Username: "alice", Resources: 12, Units: map[int]Unit{
+		1: {ID: 1, Rank: RankInfantry, Location: "europe"},
+		2: {ID: 2, Rank: RankCavalry, Location: "australia"},
+	}}
+
+	tests := []struct {
+		name          string
+		observer      Player
+		wantUnits     []int
+		wantResources int
+	}{
+		{"owner sees everything", alice, []int{1, 2}, 12},
+		{"neighbour sees adjacent units only", Player{Username: "bob", Units: map[int]Unit{
+			1: {ID: 1, Rank: RankInfantry, Location: "africa"},
+		}}, []int{1}, 0},
+		{"other neighbour sees other units", Player{Username: "carol", Units: map[int]Unit{
+			1: {ID: 1, Rank: RankInfantry, Location: "antarctica"},
+		}}, []int{2}, 0},
+		{"player without units sees nothing", Player{Username: "dave"}, []int{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.RedactPlayer(alice, tt.observer)
+			ids := []int{}
+			for id := range got.Units {
+				ids = append(ids, id)
+			}
+			slices.Sort(ids)
+			if !reflect.DeepEqual(ids, tt.wantUnits) {
+				t.Errorf("visible units = %v, want %v", ids, tt.wantUnits)
+			}
+			if got.Resources != tt.wantResources {
+				t.Errorf("Resources = %d, want %d", got.Resources, tt.wantResources)
+			}
+		})
+	}
+}
@@ -1,7 +1,10 @@
 package gamelogic
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"slices"
 )
 
 type WarOutcome int
@@ -14,92 +17,299 @@ const (
 	WarOutcomeDraw
 )
 
-func (gs *GameState) HandleWar(rw RecognitionOfWar) (outcome WarOutcome, winner string, loser string) {
+type BattleResult struct {
+	War           int64
+	Fronts        int
+	Location      Location
+	Attacker      string
+	Defender      string
+	Allies        []string
+	AttackerPower int
+	DefenderPower int
+	Damage        map[string]map[int]int
+	Casualties    map[string][]int
+	Winner        string
+	Loser         string
+}
+
+type WarReport struct {
+	Attacker string
+	Defender string
+	Winner   string
+	Loser    string
+	Battles  []BattleResult
+}
+
+func (gs *GameState) RecognizeWar(move ArmyMove) RecognitionOfWar {
+	defender := gs.GetScenario().RedactPlayer(gs.GetPlayerSnap(), move.Player)
+	return RecognitionOfWar{
+		Attacker: move.Player,
+		Defender: defender,
+		Allies:   gs.alliedSupport(getOverlappingLocations(defender, move.Player)),
+	}
+}
+
+func (gs *GameState) HandleWar(rw RecognitionOfWar) (WarOutcome, WarReport) {
 	defer fmt.Println("------------------------")
 	fmt.Println()
 	fmt.Println("==== War Declared ====")
 	fmt.Printf("%s has declared war on %s!\n", rw.Attacker.Username, rw.Defender.Username)
 
 	player := gs.GetPlayerSnap()
+	report := WarReport{
+		Attacker: rw.Attacker.Username,
+		Defender: rw.Defender.Username,
+		Battles:  []BattleResult{},
+	}
 
 	if player.Username == rw.Defender.Username {
 		fmt.Printf("%s, you published the war.\n", player.Username)
-		return WarOutcomeNotInvolved, "", ""
+		return WarOutcomeNotInvolved, report
 	}
 
 	if player.Username != rw.Attacker.Username {
 		fmt.Printf("%s, you are not involved in this war.\n", player.Username)
-		return WarOutcomeNotInvolved, "", ""
+		return WarOutcomeNotInvolved, report
 	}
 
-	overlappingLocation := getOverlappingLocation(rw.Attacker, rw.Defender)
-	if overlappingLocation == "" {
+	overlappingLocations := getOverlappingLocations(rw.Attacker, rw.Defender)
+	if len(overlappingLocations) == 0 {
 		fmt.Printf("Error! No units are in the same location. No war will be fought.\n")
-		return WarOutcomeNoUnits, "", ""
+		return WarOutcomeNoUnits, report
 	}
 
-	attackerUnits := []Unit{}
-	defenderUnits := []Unit{}
-	for _, unit := range rw.Attacker.Units {
-		if unit.Location == overlappingLocation {
-			attackerUnits = append(attackerUnits, unit)
+	attackerWins, defenderWins := 0, 0
+	for i, loc := range overlappingLocations {
+		battle := gs.fightBattle(rw, loc, rw.Seed+int64(i))
+		report.Battles = append(report.Battles, battle)
+		switch battle.Winner {
+		case rw.Attacker.Username:
+			attackerWins++
+		case rw.Defender.Username:
+			defenderWins++
 		}
 	}
-	for _, unit := range rw.Defender.Units {
-		if unit.Location == overlappingLocation {
-			defenderUnits = append(defenderUnits, unit)
+
+	fmt.Printf("%s won %d battle(s), %s won %d battle(s).\n", rw.Attacker.Username, attackerWins, rw.Defender.Username, defenderWins)
+	if attackerWins > defenderWins {
+		fmt.Printf("%s has won the war!\n", rw.Attacker.Username)
+		report.Winner, report.Loser = rw.Attacker.Username, rw.Defender.Username
+		return WarOutcomeYouWon, report
+	} else if defenderWins > attackerWins {
+		fmt.Printf("%s has won the war!\n", rw.Defender.Username)
+		fmt.Println("You have lost the war!")
+		report.Winner, report.Loser = rw.Defender.Username, rw.Attacker.Username
+		return WarOutcomeOpponentWon, report
+	}
+	fmt.Println("The war ended in a draw!")
+	report.Winner, report.Loser = rw.Attacker.Username, rw.Defender.Username
+	return WarOutcomeDraw, report
+}
+
+func defenderForces(rw RecognitionOfWar) []Player {
+	forces := []Player{rw.Defender}
+	for _, ally := range rw.Allies {
+		if ally.Username != rw.Attacker.Username && ally.Username != rw.Defender.Username {
+			forces = append(forces, ally)
 		}
 	}
+	return forces
+}
+
+func (gs *GameState) fightBattle(rw RecognitionOfWar, loc Location, seed int64) BattleResult {
+	fmt.Printf("---- Battle of %s ----\n", loc)
 
 	fmt.Printf("%s's units:\n", rw.Attacker.Username)
-	for _, unit := range attackerUnits {
+	for _, unit := range unitsInLocation(rw.Attacker, loc) {
 		fmt.Printf("  * %v\n", unit.Rank)
 	}
-	fmt.Printf("%s's units:\n", rw.Defender.Username)
-	for _, unit := range defenderUnits {
-		fmt.Printf("  * %v\n", unit.Rank)
+	for _, force := range defenderForces(rw) {
+		units := unitsInLocation(force, loc)
+		if len(units) == 0 {
+			continue
+		}
+		fmt.Printf("%s's units:\n", force.Username)
+		for _, unit := range units {
+			fmt.Printf("  * %v\n", unit.Rank)
+		}
 	}
-	attackerPower := unitsToPowerLevel(attackerUnits)
-	defenderPower := unitsToPowerLevel(defenderUnits)
-	fmt.Printf("Attacker has a power level of %v\n", attackerPower)
-	fmt.Printf("Defender has a power level of %v\n", defenderPower)
-	if attackerPower > defenderPower {
-		fmt.Printf("%s has won the war!\n", rw.Attacker.Username)
-		if player.Username == rw.Defender.Username {
-			fmt.Println("You have lost the war!")
-			gs.removeUnitsInLocation(overlappingLocation)
-			fmt.Printf("Your units in %s have been killed.\n", overlappingLocation)
-			return WarOutcomeOpponentWon, rw.Attacker.Username, rw.Defender.Username
+
+	result := gs.GetScenario().battle(rw, loc, seed)
+	fmt.Printf("Attacker has a power level of %v\n", result.AttackerPower)
+	fmt.Printf("Defender has a power level of %v\n", result.DefenderPower)
+	fmt.Printf("%s lost %d unit(s), %s lost %d unit(s).\n", rw.Attacker.Username, len(result.Casualties[rw.Attacker.Username]), rw.Defender.Username, len(result.Casualties[rw.Defender.Username]))
+
+	switch result.Winner {
+	case "":
+		fmt.Printf("The battle of %s ended in a draw!\n", loc)
+	default:
+		fmt.Printf("%s has won the battle of %s!\n", result.Winner, loc)
+	}
+
+	gs.applyBattleResult(result)
+	return result
+}
+
+func (s Scenario) battle(rw RecognitionOfWar, loc Location, seed int64) BattleResult {
+	attackerUnits := unitsInLocation(rw.Attacker, loc)
+	forces := defenderForces(rw)
+	defenderUnits := []Unit{}
+	allies := []string{}
+	for _, force := range forces {
+		units := unitsInLocation(force, loc)
+		if len(units) == 0 {
+			continue
 		}
-		return WarOutcomeYouWon, rw.Attacker.Username, rw.Defender.Username
-	} else if defenderPower > attackerPower {
-		fmt.Printf("%s has won the war!\n", rw.Defender.Username)
-		if player.Username == rw.Attacker.Username {
-			fmt.Println("You have lost the war!")
-			gs.removeUnitsInLocation(overlappingLocation)
-			fmt.Printf("Your units in %s have been killed.\n", overlappingLocation)
-			return WarOutcomeOpponentWon, rw.Defender.Username, rw.Attacker.Username
+		if force.Username != rw.Defender.Username {
+			allies = append(allies, force.Username)
 		}
-		return WarOutcomeYouWon, rw.Defender.Username, rw.Attacker.Username
+		defenderUnits = append(defenderUnits, units...)
 	}
-	fmt.Println("The war ended in a draw!")
-	fmt.Printf("Your units in %s have been killed.\n", overlappingLocation)
-	gs.removeUnitsInLocation(overlappingLocation)
-	return WarOutcomeDraw, rw.Attacker.Username, rw.Defender.Username
+
+	combat := s.Resolver().Resolve(attackerUnits, defenderUnits, seed)
+	result := BattleResult{
+		War:           rw.Seed,
+		Fronts:        len(getOverlappingLocations(rw.Attacker, rw.Defender)),
+		Location:      loc,
+		Attacker:      rw.Attacker.Username,
+		Defender:      rw.Defender.Username,
+		Allies:        allies,
+		AttackerPower: combat.AttackerPower,
+		DefenderPower: combat.DefenderPower,
+		Damage:        map[string]map[int]int{},
+		Casualties:    map[string][]int{},
+	}
+	result.record(s, rw.Attacker.Username, attackerUnits, combat.AttackerDamage)
+	offset := 0
+	for _, force := range forces {
+		units := unitsInLocation(force, loc)
+		result.record(s, force.Username, units, combat.DefenderDamage[offset:offset+len(units)])
+		offset += len(units)
+	}
+
+	switch combat.Victor {
+	case SideAttacker:
+		result.Winner, result.Loser = rw.Attacker.Username, rw.Defender.Username
+	case SideDefender:
+		result.Winner, result.Loser = rw.Defender.Username, rw.Attacker.Username
+	}
+	return result
 }
 
-func unitsToPowerLevel(units []Unit) int {
-	power := 0
-	for _, unit := range units {
-		if unit.Rank == RankArtillery {
-			power += 10
+func (gs *GameState) TrackWar(rw RecognitionOfWar) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	gs.wars[rw.Seed] = rw
+}
+
+func (gs *GameState) ConfirmBattle(br BattleResult) bool {
+	gs.mu.RLock()
+	rw, ok := gs.wars[br.War]
+	gs.mu.RUnlock()
+	if !ok || rw.Attacker.Username != br.Attacker || rw.Defender.Username != br.Defender {
+		return false
+	}
+
+	i := slices.Index(getOverlappingLocations(rw.Attacker, rw.Defender), br.Location)
+	if i < 0 {
+		return false
+	}
+	if !SameBattle(gs.GetScenario().battle(rw, br.Location, rw.Seed+int64(i)), br) {
+		return false
+	}
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	gs.confirmed[br.War]++
+	if gs.confirmed[br.War] >= br.Fronts {
+		delete(gs.wars, br.War)
+		delete(gs.confirmed, br.War)
+	}
+	return true
+}
+
+func SameBattle(a, b BattleResult) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(encodedA, encodedB)
+}
+
+func (br *BattleResult) record(s Scenario, username string, units []Unit, damage []int) {
+	dealt := map[int]int{}
+	casualties := []int{}
+	for i, unit := range units {
+		if damage[i] <= 0 {
+			continue
 		}
-		if unit.Rank == RankCavalry {
-			power += 5
+		dealt[unit.ID] = damage[i]
+		if damage[i] >= unitHealth(s, unit) {
+			casualties = append(casualties, unit.ID)
 		}
-		if unit.Rank == RankInfantry {
-			power += 1
+	}
+	if len(units) == 0 {
+		return
+	}
+	br.Damage[username] = dealt
+	br.Casualties[username] = casualties
+}
+
+func (gs *GameState) HandleBattleResult(br BattleResult) bool {
+	username := gs.GetUsername()
+	allied := slices.Contains(br.Allies, username)
+	if br.Defender != username && !allied {
+		return false
+	}
+
+	defer fmt.Println("------------------------")
+	fmt.Println()
+	fmt.Printf("==== Battle of %s ====\n", br.Location)
+	if allied {
+		fmt.Printf("You came to the aid of your ally %s.\n", br.Defender)
+	}
+	switch br.Winner {
+	case "":
+		fmt.Printf("Your battle against %s ended in a draw!\n", br.Attacker)
+	case br.Defender:
+		fmt.Printf("You have won the battle against %s!\n", br.Attacker)
+	default:
+		fmt.Printf("You have lost the battle against %s!\n", br.Attacker)
+	}
+	gs.applyBattleResult(br)
+	return true
+}
+
+func (gs *GameState) applyBattleResult(br BattleResult) {
+	gs.mu.Lock()
+	player, killed := gs.scenario.ApplyBattle(gs.Player, br)
+	gs.Player = player
+	gs.mu.Unlock()
+
+	username := player.Username
+	if len(br.Damage[username]) > 0 {
+		fmt.Printf("%d of your units in %s were hit and %d of them have been killed.\n", len(br.Damage[username]), br.Location, len(killed))
+	}
+	if br.rewards(username) {
+		fmt.Printf("Your surviving units in %s have gained experience.\n", br.Location)
+	}
+}
+
+func unitsInLocation(p Player, loc Location) []Unit {
+	units := []Unit{}
+	for _, unit := range p.Units {
+		if unit.Location == loc {
+			units = append(units, unit)
 		}
 	}
+	slices.SortFunc(units, func(a, b Unit) int {
+		return a.ID - b.ID
+	})
+	return units
+}
+
+func (s Scenario) unitsToPowerLevel(units []Unit) int {
+	power := 0
+	for _, unit := range units {
+		power += s.unitPower(unit)
+	}
 	return power
 }
@@ -0,0 +1,80 @@
+package gamelogic
+
+import "testing"
+
+func TestConfirmBattle(t *testing.T) {
+	rw := RecognitionOfWar{
+		Attacker: Player{Username: "alice", Units: map[int]Unit{
+			1: {ID: 1, Rank: RankArtillery, Location: "europe"},
+			2: {ID: 2, Rank: RankInfantry, Location: "asia"},
+		}},
+		Defender: Player{Username: "bob", Units: map[int]Unit{
+			1: {ID: 1, Rank: RankInfantry, Location: "europe"},
+			2: {ID: 2, Rank: RankCavalry, Location: "asia"},
+		}},
+		Seed: 42,
+	}
+	s := DefaultScenario()
+	s.Combat = CombatDice
+	europe := s.battle(rw, "europe", rw.Seed+1)
+	asia := s.battle(rw, "asia", rw.Seed)
+
+	tests := []struct {
+		name   string
+		battle func() BattleResult
+		want   bool
+	}{
+		{"matching battle", func() BattleResult { return europe }, true},
+		{"matching second front", func() BattleResult { return asia }, true},
+		{"tampered damage", func() BattleResult {
+			br := s.battle(rw, "europe", rw.Seed+1)
+			br.Damage["bob"] = map[int]int{}
+			return br
+		}, false},
+		{"tampered winner", func() BattleResult {
+			br := s.battle(rw, "asia", rw.Seed)
+			br.Winner, br.Loser = br.Loser, br.Winner
+			return br
+		}, false},
+		{"wrong seed for the front", func() BattleResult { return s.battle(rw, "europe", rw.Seed) }, false},
+		{"unknown war", func() BattleResult {
+			br := s.battle(rw, "europe", rw.Seed+1)
+			br.War++
+			return br
+		}, false},
+		{"wrong attacker", func() BattleResult {
+			br := s.battle(rw, "europe", rw.Seed+1)
+			br.Attacker = "carol"
+			return br
+		}, false},
+		{"location outside the war", func() BattleResult {
+			br := s.battle(rw, "europe", rw.Seed+1)
+			br.Location = "africa"
+			return br
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := NewGameState("test", "bob")
+			gs.SetScenario(s)
+			gs.TrackWar(rw)
+			if got := gs.ConfirmBattle(tt.battle()); got != tt.want {
+				t.Errorf("ConfirmBattle() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("forgets the war once every front is confirmed", func(t *testing.T) {
+		gs := NewGameState("test", "bob")
+		gs.SetScenario(s)
+		gs.TrackWar(rw)
+		for _, br := range []BattleResult{europe, asia} {
+			if !gs.ConfirmBattle(br) {
+				t.Fatalf("battle of %s was not confirmed", br.Location)
+			}
+		}
+		if gs.ConfirmBattle(europe) {
+			t.Error("confirmed a battle after the war was over")
+		}
+	})
+}
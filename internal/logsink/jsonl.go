@@ -0,0 +1,301 @@
+package logsink
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+const (
+	segmentTimeFormat   = "20060102-150405.000000"
+	compressedExtension = ".gz"
+)
+
+type Options struct {
+	Path         string
+	MaxSize      int64
+	MaxAge       time.Duration
+	SyncInterval time.Duration
+	Compress     bool
+}
+
+type JSONLines struct {
+	opts        Options
+	file        *os.File
+	buf         *bufio.Writer
+	size        int64
+	opened      time.Time
+	dirty       bool
+	compressing *sync.WaitGroup
+	done        chan struct{}
+	stopped     chan struct{}
+	mu          *sync.Mutex
+}
+
+func NewJSONLines(opts Options) (*JSONLines, error) {
+	if opts.Path == "" {
+		return nil, errors.New("log path must not be empty")
+	}
+	if opts.SyncInterval <= 0 {
+		opts.SyncInterval = time.Second
+	}
+
+	w := &JSONLines{
+		opts:        opts,
+		compressing: &sync.WaitGroup{},
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+		mu:          &sync.Mutex{},
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+
+	go w.run()
+	return w, nil
+}
+
+func (w *JSONLines) Write(logs ...routing.GameLog) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return errors.New("log sink is closed")
+	}
+
+	batch := []byte{}
+	for _, gl := range logs {
+		line, err := json.Marshal(NewRecord(gl))
+		if err != nil {
+			err := fmt.Errorf("could not encode log record: %w", err)
+			return err
+		}
+		batch = append(batch, line...)
+		batch = append(batch, '\n')
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+
+	if w.shouldRotate(int64(len(batch))) {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := w.buf.Write(batch)
+	w.size += int64(n)
+	w.dirty = true
+	if err != nil {
+		err := fmt.Errorf("could not write log records: %w", err)
+		return err
+	}
+	return nil
+}
+
+func (w *JSONLines) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return errors.New("log sink is closed")
+	}
+	return w.sync()
+}
+
+func (w *JSONLines) Close() error {
+	w.mu.Lock()
+	if w.file == nil {
+		w.mu.Unlock()
+		return nil
+	}
+	close(w.done)
+	w.mu.Unlock()
+	<-w.stopped
+
+	w.mu.Lock()
+	err := w.closeFile()
+	w.mu.Unlock()
+	w.compressing.Wait()
+	return err
+}
+
+func (w *JSONLines) run() {
+	defer close(w.stopped)
+	ticker := time.NewTicker(w.opts.SyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			w.mu.Lock()
+			if w.opts.MaxAge > 0 && w.size > 0 && time.Since(w.opened) >= w.opts.MaxAge {
+				if err := w.rotate(); err != nil {
+					slog.Error("Failed to rotate game log", "error", err)
+				}
+			}
+			if err := w.sync(); err != nil {
+				slog.Error("Failed to sync game log", "error", err)
+			}
+			w.mu.Unlock()
+		}
+	}
+}
+
+func (w *JSONLines) open() error {
+	if dir := filepath.Dir(w.opts.Path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			err := fmt.Errorf("could not create log directory: %w", err)
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(w.opts.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		err := fmt.Errorf("could not open log file: %w", err)
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		err := fmt.Errorf("could not stat log file: %w", err)
+		return err
+	}
+
+	w.file = f
+	w.buf = bufio.NewWriter(f)
+	w.size = info.Size()
+	w.opened = time.Now()
+	return nil
+}
+
+func (w *JSONLines) sync() error {
+	if !w.dirty {
+		return nil
+	}
+	if err := w.buf.Flush(); err != nil {
+		err := fmt.Errorf("could not flush log file: %w", err)
+		return err
+	}
+	if err := w.file.Sync(); err != nil {
+		err := fmt.Errorf("could not sync log file: %w", err)
+		return err
+	}
+	w.dirty = false
+	return nil
+}
+
+func (w *JSONLines) closeFile() error {
+	if err := w.sync(); err != nil {
+		return err
+	}
+	if err := w.file.Close(); err != nil {
+		err := fmt.Errorf("could not close log file: %w", err)
+		return err
+	}
+	w.file = nil
+	w.buf = nil
+	return nil
+}
+
+func (w *JSONLines) shouldRotate(next int64) bool {
+	if w.size == 0 {
+		return false
+	}
+	if w.opts.MaxSize > 0 && w.size+next > w.opts.MaxSize {
+		return true
+	}
+	return w.opts.MaxAge > 0 && time.Since(w.opened) >= w.opts.MaxAge
+}
+
+func (w *JSONLines) rotate() error {
+	if err := w.closeFile(); err != nil {
+		return err
+	}
+
+	segment := w.opts.Path + "." + time.Now().UTC().Format(segmentTimeFormat)
+	if err := os.Rename(w.opts.Path, segment); err != nil {
+		slog.Error("Failed to rotate game log, appending to the current file", "path", w.opts.Path, "error", err)
+		return w.open()
+	}
+	if w.opts.Compress {
+		w.compressing.Add(1)
+		go func() {
+			defer w.compressing.Done()
+			if err := compress(segment); err != nil {
+				slog.Error("Failed to compress game log segment", "segment", segment, "error", err)
+			}
+		}()
+	}
+
+	return w.open()
+}
+
+func compress(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		err := fmt.Errorf("could not open segment: %w", err)
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+compressedExtension, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		err := fmt.Errorf("could not create compressed segment: %w", err)
+		return err
+	}
+	defer dst.Close()
+
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		err := fmt.Errorf("could not compress segment: %w", err)
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		err := fmt.Errorf("could not finish compressed segment: %w", err)
+		return err
+	}
+	if err := dst.Sync(); err != nil {
+		err := fmt.Errorf("could not sync compressed segment: %w", err)
+		return err
+	}
+	return os.Remove(path)
+}
+
+func Segments(path string) ([]string, error) {
+	matches, err := filepath.Glob(path + ".*")
+	if err != nil {
+		err := fmt.Errorf("could not list log segments: %w", err)
+		return nil, err
+	}
+
+	segments := []string{}
+	for _, match := range matches {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(match, path+"."), compressedExtension)
+		if _, err := time.Parse(segmentTimeFormat, stamp); err != nil {
+			continue
+		}
+		if strings.HasSuffix(match, compressedExtension) {
+			if _, err := os.Stat(strings.TrimSuffix(match, compressedExtension)); err == nil {
+				continue
+			}
+		}
+		segments = append(segments, match)
+	}
+	slices.Sort(segments)
+
+	if _, err := os.Stat(path); err == nil {
+		segments = append(segments, path)
+	}
+	return segments, nil
+}
@@ -0,0 +1,52 @@
+package logsink
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSegments(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "game.jsonl")
+	files := []string{
+		"game.jsonl",
+		"game.jsonl.20240501-120000.000000.gz",
+		"game.jsonl.20240502-120000.000000",
+		"game.jsonl.20240503-120000.000000",
+		"game.jsonl.20240503-120000.000000.gz",
+		"game.jsonl.backup",
+		"game.jsonl.20240504-120000.000000.tmp",
+		"other.jsonl.20240501-120000.000000",
+	}
+	for _, name := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	segments, err := Segments(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		path + ".20240501-120000.000000.gz",
+		path + ".20240502-120000.000000",
+		path + ".20240503-120000.000000",
+		path,
+	}
+	if !slices.Equal(segments, want) {
+		t.Errorf("Segments() = %v, want %v", segments, want)
+	}
+}
+
+func TestSegmentsWithoutActiveFile(t *testing.T) {
+	segments, err := Segments(filepath.Join(t.TempDir(), "game.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) != 0 {
+		t.Errorf("Segments() = %v, want none", segments)
+	}
+}
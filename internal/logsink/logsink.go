@@ -0,0 +1,74 @@
+package logsink
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+type Sink interface {
+	Write(logs ...routing.GameLog) error
+	Sync() error
+	Close() error
+}
+
+type Record struct {
+	ID       string    `json:"id,omitempty"`
+	Time     time.Time `json:"time"`
+	GameID   string    `json:"game_id,omitempty"`
+	Username string    `json:"username"`
+	Message  string    `json:"message"`
+}
+
+func NewRecord(gl routing.GameLog) Record {
+	return Record{
+		ID:       gl.ID,
+		Time:     gl.CurrentTime,
+		GameID:   gl.GameID,
+		Username: gl.Username,
+		Message:  gl.Message,
+	}
+}
+
+func (r Record) GameLog() routing.GameLog {
+	return routing.GameLog{
+		ID:          r.ID,
+		CurrentTime: r.Time,
+		GameID:      r.GameID,
+		Username:    r.Username,
+		Message:     r.Message,
+	}
+}
+
+type tee []Sink
+
+func Tee(sinks ...Sink) Sink {
+	return tee(sinks)
+}
+
+func (t tee) Write(logs ...routing.GameLog) error {
+	for _, sink := range t {
+		if err := sink.Write(logs...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t tee) Sync() error {
+	for _, sink := range t {
+		if err := sink.Sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t tee) Close() error {
+	var errs []error
+	for _, sink := range t {
+		errs = append(errs, sink.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,97 @@
+package logsink
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+type Filter struct {
+	Username string
+	GameID   string
+	Contains string
+	Since    time.Time
+	Until    time.Time
+}
+
+func (f Filter) Match(r Record) bool {
+	if f.Username != "" && r.Username != f.Username {
+		return false
+	}
+	if f.GameID != "" && r.GameID != f.GameID {
+		return false
+	}
+	if f.Contains != "" && !strings.Contains(strings.ToLower(r.Message), strings.ToLower(f.Contains)) {
+		return false
+	}
+	if !f.Since.IsZero() && r.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && r.Time.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+func Read(path string, fn func(Record) error) error {
+	segments, err := Segments(path)
+	if err != nil {
+		return err
+	}
+	for _, segment := range segments {
+		if err := readSegment(segment, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readSegment(path string, fn func(Record) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		err := fmt.Errorf("could not open log segment: %w", err)
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, compressedExtension) {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			err := fmt.Errorf("could not decompress log segment %s: %w", path, err)
+			return err
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	return Scan(r, fn)
+}
+
+func Scan(r io.Reader, fn func(Record) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil {
+			continue
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		err := fmt.Errorf("could not read log segment: %w", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package logsink
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFilterMatch(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	record := Record{Time: now, GameID: "default", Username: "alice", Message: "Alice Won A War"}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"empty filter", Filter{}, true},
+		{"username", Filter{Username: "alice"}, true},
+		{"other username", Filter{Username: "bob"}, false},
+		{"game", Filter{GameID: "default"}, true},
+		{"other game", Filter{GameID: "match-1"}, false},
+		{"contains ignores case", Filter{Contains: "won a war"}, true},
+		{"does not contain", Filter{Contains: "lost"}, false},
+		{"since before", Filter{Since: now.Add(-time.Minute)}, true},
+		{"since exactly", Filter{Since: now}, true},
+		{"since after", Filter{Since: now.Add(time.Minute)}, false},
+		{"until after", Filter{Until: now.Add(time.Minute)}, true},
+		{"until before", Filter{Until: now.Add(-time.Minute)}, false},
+		{"every field", Filter{Username: "alice", GameID: "default", Contains: "war", Since: now.Add(-time.Hour), Until: now.Add(time.Hour)}, true},
+		{"every field but one", Filter{Username: "alice", GameID: "default", Contains: "peace", Since: now.Add(-time.Hour), Until: now.Add(time.Hour)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(record); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
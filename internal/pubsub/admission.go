@@ -0,0 +1,42 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const HeaderRejectReason = "x-peril-reject-reason"
+
+func WithAdmission[T any](admit func(key string, v T) error) SubscribeOption[T] {
+	return func(c *subscribeConfig[T]) {
+		c.admit = admit
+	}
+}
+
+func (c subscribeConfig[T]) admission(delivery amqp.Delivery, v T) error {
+	if c.admit == nil {
+		return nil
+	}
+	return c.admit(delivery.RoutingKey, v)
+}
+
+func deadLetter(ch *amqp.Channel, delivery amqp.Delivery, reason string) error {
+	headers := amqp.Table{}
+	for k, v := range delivery.Headers {
+		headers[k] = v
+	}
+	headers[HeaderRejectReason] = reason
+
+	if err := ch.PublishWithContext(context.Background(), routing.ExchangePerilDLX, delivery.RoutingKey, false, false, amqp.Publishing{
+		ContentType: delivery.ContentType,
+		Headers:     headers,
+		Body:        delivery.Body,
+	}); err != nil {
+		err := fmt.Errorf("failed to dead-letter message: %w", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package pubsub
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+type BatchOptions struct {
+	Size   int
+	Window time.Duration
+}
+
+func SubscribeGobBatch[T any](conn *amqp.Connection, exchange, queueName, key string, queueType SimpleQueueType, batch BatchOptions, handler func([]T) AckType, opts ...SubscribeOption[T]) error {
+	if batch.Size <= 0 || batch.Window <= 0 {
+		return errors.New("batch size and window must be positive")
+	}
+	config := newSubscribeConfig(opts)
+
+	ch, queue, err := DeclareAndBind(conn, exchange, queueName, key, queueType)
+	if err != nil {
+		err := fmt.Errorf("failed to declare and bind: %w", err)
+		return err
+	}
+
+	if err := ch.Qos(batch.Size, 0, false); err != nil {
+		err := fmt.Errorf("failed to set QoS: %w", err)
+		return err
+	}
+
+	deliveriesCh, err := ch.Consume(queue.Name, "", false, false, false, false, nil)
+	if err != nil {
+		err := fmt.Errorf("failed to consume messages: %w", err)
+		return err
+	}
+
+	go func() {
+		values := make([]T, 0, batch.Size)
+		deliveries := make([]amqp.Delivery, 0, batch.Size)
+		timer := time.NewTimer(batch.Window)
+		timer.Stop()
+
+		flush := func() {
+			if len(values) == 0 {
+				return
+			}
+			settleBatch(deliveries, handler(values))
+			values = make([]T, 0, batch.Size)
+			deliveries = make([]amqp.Delivery, 0, batch.Size)
+		}
+
+		for {
+			select {
+			case delivery, ok := <-deliveriesCh:
+				if !ok {
+					timer.Stop()
+					flush()
+					return
+				}
+				v, ok := config.accept(ch, delivery, decodeGob[T])
+				if !ok {
+					continue
+				}
+				if len(values) == 0 {
+					timer.Reset(batch.Window)
+				}
+				values = append(values, v)
+				deliveries = append(deliveries, delivery)
+				if len(values) >= batch.Size {
+					timer.Stop()
+					flush()
+				}
+
+			case <-timer.C:
+				flush()
+			}
+		}
+	}()
+
+	return nil
+}
+
+func settleBatch(deliveries []amqp.Delivery, ackType AckType) {
+	size := len(deliveries)
+	switch ackType {
+	case Ack:
+		for _, delivery := range deliveries {
+			if err := delivery.Ack(false); err != nil {
+				slog.Error("Failed to acknowledge message", "key", delivery.RoutingKey, "error", err)
+			}
+		}
+		slog.Info("Batch acked", "size", size)
+	case NackRequeue:
+		discarded := 0
+		for _, delivery := range deliveries {
+			requeue := !delivery.Redelivered
+			if !requeue {
+				slog.Warn("Message failed again after redelivery, dead-lettering it", "key", delivery.RoutingKey)
+				discarded++
+			}
+			if err := delivery.Nack(false, requeue); err != nil {
+				slog.Error("Failed to nack message", "key", delivery.RoutingKey, "requeue", requeue, "error", err)
+			}
+		}
+		slog.Info("Batch nacked with requeue", "size", size, "discarded", discarded)
+	case NackDiscard:
+		for _, delivery := range deliveries {
+			if err := delivery.Nack(false, false); err != nil {
+				slog.Error("Failed to nack message", "key", delivery.RoutingKey, "requeue", false, "error", err)
+			}
+		}
+		slog.Info("Batch nacked without requeue", "size", size)
+
+	default:
+		slog.Error("Invalid AckType returned by batch handler", "ackType", ackType)
+	}
+}
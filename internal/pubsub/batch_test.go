@@ -0,0 +1,89 @@
+package pubsub
+
+import (
+	"reflect"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+type settlement struct {
+	tag     uint64
+	ack     bool
+	requeue bool
+}
+
+type fakeAcknowledger struct {
+	settled []settlement
+}
+
+func (f *fakeAcknowledger) Ack(tag uint64, multiple bool) error {
+	f.settled = append(f.settled, settlement{tag: tag, ack: true})
+	return nil
+}
+
+func (f *fakeAcknowledger) Nack(tag uint64, multiple, requeue bool) error {
+	f.settled = append(f.settled, settlement{tag: tag, requeue: requeue})
+	return nil
+}
+
+func (f *fakeAcknowledger) Reject(tag uint64, requeue bool) error {
+	return f.Nack(tag, false, requeue)
+}
+
+func TestSettleBatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		redelivered []bool
+		ackType     AckType
+		want        []settlement
+	}{
+		{
+			name:        "ack settles every message",
+			redelivered: []bool{false, true, false},
+			ackType:     Ack,
+			want:        []settlement{{tag: 1, ack: true}, {tag: 2, ack: true}, {tag: 3, ack: true}},
+		},
+		{
+			name:        "requeue keeps fresh messages",
+			redelivered: []bool{false, false},
+			ackType:     NackRequeue,
+			want:        []settlement{{tag: 1, requeue: true}, {tag: 2, requeue: true}},
+		},
+		{
+			name:        "requeue dead-letters only redelivered messages",
+			redelivered: []bool{false, true, false},
+			ackType:     NackRequeue,
+			want:        []settlement{{tag: 1, requeue: true}, {tag: 2}, {tag: 3, requeue: true}},
+		},
+		{
+			name:        "discard dead-letters every message",
+			redelivered: []bool{false, true},
+			ackType:     NackDiscard,
+			want:        []settlement{{tag: 1}, {tag: 2}},
+		},
+		{
+			name:        "invalid ack type settles nothing",
+			redelivered: []bool{false},
+			ackType:     AckType(99),
+			want:        nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ack := &fakeAcknowledger{}
+			deliveries := []amqp.Delivery{}
+			for i, redelivered := range tt.redelivered {
+				deliveries = append(deliveries, amqp.Delivery{
+					Acknowledger: ack,
+					DeliveryTag:  uint64(i + 1),
+					Redelivered:  redelivered,
+				})
+			}
+			settleBatch(deliveries, tt.ackType)
+			if !reflect.DeepEqual(ack.settled, tt.want) {
+				t.Errorf("settled %+v, want %+v", ack.settled, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package pubsub
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const ContentTypeEnvelope = "application/vnd.peril.envelope+json"
+
+var errNotForUs = errors.New("message is encrypted for another subscriber")
+
+type Envelope struct {
+	KeyID        string
+	EphemeralKey []byte
+	Nonce        []byte
+	Ciphertext   []byte
+}
+
+type EncryptionKeys interface {
+	EncryptionKey(username string) (*ecdh.PublicKey, bool)
+}
+
+func KeyID(key *ecdh.PublicKey) string {
+	sum := sha256.Sum256(key.Bytes())
+	return hex.EncodeToString(sum[:8])
+}
+
+func WithEncryption(recipient *ecdh.PublicKey) PublishOption {
+	return func(key string, msg *amqp.Publishing) error {
+		ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			err := fmt.Errorf("failed to generate ephemeral key: %w", err)
+			return err
+		}
+		aead, err := envelopeCipher(ephemeral, recipient, ephemeral.PublicKey(), recipient)
+		if err != nil {
+			return err
+		}
+
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			err := fmt.Errorf("failed to generate nonce: %w", err)
+			return err
+		}
+
+		body, err := json.Marshal(Envelope{
+			KeyID:        KeyID(recipient),
+			EphemeralKey: ephemeral.PublicKey().Bytes(),
+			Nonce:        nonce,
+			Ciphertext:   aead.Seal(nil, nonce, msg.Body, []byte(key)),
+		})
+		if err != nil {
+			err := fmt.Errorf("failed to marshal envelope: %w", err)
+			return err
+		}
+		msg.ContentType = ContentTypeEnvelope
+		msg.Body = body
+		return nil
+	}
+}
+
+func WithDecryption[T any](key *ecdh.PrivateKey) SubscribeOption[T] {
+	return func(c *subscribeConfig[T]) {
+		c.decryptKey = key
+	}
+}
+
+func (c subscribeConfig[T]) decrypt(delivery amqp.Delivery) ([]byte, error) {
+	encrypted := delivery.ContentType == ContentTypeEnvelope
+	switch {
+	case c.decryptKey == nil && encrypted:
+		return nil, errNotForUs
+	case c.decryptKey == nil:
+		return delivery.Body, nil
+	case !encrypted:
+		return nil, errors.New("message is not encrypted")
+	}
+
+	var envelope Envelope
+	if err := json.Unmarshal(delivery.Body, &envelope); err != nil {
+		err := fmt.Errorf("failed to unmarshal envelope: %w", err)
+		return nil, err
+	}
+	if envelope.KeyID != KeyID(c.decryptKey.PublicKey()) {
+		return nil, fmt.Errorf("message is encrypted for key %s", envelope.KeyID)
+	}
+	ephemeral, err := ecdh.X25519().NewPublicKey(envelope.EphemeralKey)
+	if err != nil {
+		err := fmt.Errorf("invalid ephemeral key: %w", err)
+		return nil, err
+	}
+
+	aead, err := envelopeCipher(c.decryptKey, ephemeral, ephemeral, c.decryptKey.PublicKey())
+	if err != nil {
+		return nil, err
+	}
+	if len(envelope.Nonce) != aead.NonceSize() {
+		return nil, errors.New("invalid envelope nonce")
+	}
+	plaintext, err := aead.Open(nil, envelope.Nonce, envelope.Ciphertext, []byte(delivery.RoutingKey))
+	if err != nil {
+		err := fmt.Errorf("failed to decrypt envelope: %w", err)
+		return nil, err
+	}
+	return plaintext, nil
+}
+
+func envelopeCipher(private *ecdh.PrivateKey, peer, ephemeral, recipient *ecdh.PublicKey) (cipher.AEAD, error) {
+	shared, err := private.ECDH(peer)
+	if err != nil {
+		err := fmt.Errorf("failed to derive shared secret: %w", err)
+		return nil, err
+	}
+
+	digest := sha256.New()
+	digest.Write(shared)
+	digest.Write(ephemeral.Bytes())
+	digest.Write(recipient.Bytes())
+	block, err := aes.NewCipher(digest.Sum(nil))
+	if err != nil {
+		err := fmt.Errorf("failed to create cipher: %w", err)
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		err := fmt.Errorf("failed to create GCM: %w", err)
+		return nil, err
+	}
+	return aead, nil
+}
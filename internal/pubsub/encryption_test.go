@@ -0,0 +1,98 @@
+package pubsub
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func newTestKey(t *testing.T) *ecdh.PrivateKey {
+	t.Helper()
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestDecrypt(t *testing.T) {
+	server := newTestKey(t)
+	other := newTestKey(t)
+	const key = "game.player_state.alice"
+	const body = `{"Username":"alice"}`
+
+	encrypt := func(recipient *ecdh.PublicKey, key string) amqp.Delivery {
+		msg := amqp.Publishing{ContentType: "application/json", Body: []byte(body)}
+		if err := WithEncryption(recipient)(key, &msg); err != nil {
+			t.Fatal(err)
+		}
+		return amqp.Delivery{RoutingKey: key, ContentType: msg.ContentType, Body: msg.Body}
+	}
+	tamper := func(d amqp.Delivery, change func(*Envelope)) amqp.Delivery {
+		var envelope Envelope
+		if err := json.Unmarshal(d.Body, &envelope); err != nil {
+			t.Fatal(err)
+		}
+		change(&envelope)
+		body, err := json.Marshal(envelope)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.Body = body
+		return d
+	}
+
+	tests := []struct {
+		name       string
+		decryptKey *ecdh.PrivateKey
+		delivery   func() amqp.Delivery
+		want       string
+		wantErr    bool
+	}{
+		{"round trip", server, func() amqp.Delivery { return encrypt(server.PublicKey(), key) }, body, false},
+		{"plaintext without a key", nil, func() amqp.Delivery {
+			return amqp.Delivery{RoutingKey: key, ContentType: "application/json", Body: []byte(body)}
+		}, body, false},
+		{"plaintext where encryption is required", server, func() amqp.Delivery {
+			return amqp.Delivery{RoutingKey: key, ContentType: "application/json", Body: []byte(body)}
+		}, "", true},
+		{"encrypted for another key", server, func() amqp.Delivery { return encrypt(other.PublicKey(), key) }, "", true},
+		{"replayed on another key", server, func() amqp.Delivery {
+			d := encrypt(server.PublicKey(), key)
+			d.RoutingKey = "game.player_state.bob"
+			return d
+		}, "", true},
+		{"tampered ciphertext", server, func() amqp.Delivery {
+			return tamper(encrypt(server.PublicKey(), key), func(e *Envelope) { e.Ciphertext[0] ^= 1 })
+		}, "", true},
+		{"truncated nonce", server, func() amqp.Delivery {
+			return tamper(encrypt(server.PublicKey(), key), func(e *Envelope) { e.Nonce = e.Nonce[:4] })
+		}, "", true},
+		{"malformed envelope", server, func() amqp.Delivery {
+			return amqp.Delivery{RoutingKey: key, ContentType: ContentTypeEnvelope, Body: []byte("not json")}
+		}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newSubscribeConfig([]SubscribeOption[testMessage]{WithDecryption[testMessage](tt.decryptKey)})
+			got, err := c.decrypt(tt.delivery())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("body = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("encrypted without a key", func(t *testing.T) {
+		c := newSubscribeConfig([]SubscribeOption[testMessage]{})
+		if _, err := c.decrypt(encrypt(server.PublicKey(), key)); !errors.Is(err, errNotForUs) {
+			t.Errorf("error = %v, want %v", err, errNotForUs)
+		}
+	})
+}
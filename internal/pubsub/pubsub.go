@@ -3,8 +3,11 @@ package pubsub
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
 	"encoding/gob"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
@@ -29,17 +32,25 @@ func (s SimpleQueueType) String() string {
 	return typeName[s]
 }
 
-func PublishJSON[T any](ch *amqp.Channel, exchange, key string, val T) error {
+func PublishJSON[T any](ch *amqp.Channel, exchange, key string, val T, opts ...PublishOption) error {
 	body, err := json.Marshal(val)
 	if err != nil {
 		err := fmt.Errorf("failed to marshal JSON: %w", err)
 		return err
 	}
 
-	if err := ch.PublishWithContext(context.Background(), exchange, key, false, false, amqp.Publishing{
+	msg := amqp.Publishing{
 		ContentType: "application/json",
 		Body:        body,
-	}); err != nil {
+	}
+	for _, opt := range opts {
+		if err := opt(key, &msg); err != nil {
+			err := fmt.Errorf("failed to apply publish option: %w", err)
+			return err
+		}
+	}
+
+	if err := ch.PublishWithContext(context.Background(), exchange, key, false, false, msg); err != nil {
 		err := fmt.Errorf("failed to publish message: %w", err)
 		return err
 	}
@@ -47,7 +58,7 @@ func PublishJSON[T any](ch *amqp.Channel, exchange, key string, val T) error {
 	return nil
 }
 
-func PublishGob[T any](ch *amqp.Channel, exchange, key string, val T) error {
+func PublishGob[T any](ch *amqp.Channel, exchange, key string, val T, opts ...PublishOption) error {
 	var encoded bytes.Buffer
 	enc := gob.NewEncoder(&encoded)
 	if err := enc.Encode(val); err != nil {
@@ -55,10 +66,18 @@ func PublishGob[T any](ch *amqp.Channel, exchange, key string, val T) error {
 		return err
 	}
 
-	if err := ch.PublishWithContext(context.Background(), exchange, key, false, false, amqp.Publishing{
+	msg := amqp.Publishing{
 		ContentType: "application/gob",
 		Body:        encoded.Bytes(),
-	}); err != nil {
+	}
+	for _, opt := range opts {
+		if err := opt(key, &msg); err != nil {
+			err := fmt.Errorf("failed to apply publish option: %w", err)
+			return err
+		}
+	}
+
+	if err := ch.PublishWithContext(context.Background(), exchange, key, false, false, msg); err != nil {
 		err := fmt.Errorf("failed to publish message: %w", err)
 		return err
 	}
@@ -66,20 +85,35 @@ func PublishGob[T any](ch *amqp.Channel, exchange, key string, val T) error {
 	return nil
 }
 
-func PublishGamelog(ch *amqp.Channel, username, message string) error {
+func PublishGamelog(ch *amqp.Channel, gameID, username, message string, opts ...PublishOption) error {
 	exchange := routing.ExchangePerilTopic
-	key := routing.GameLogSlug + "." + username
+	key := routing.GameKey(gameID, routing.GameLogSlug, username)
+	id, err := newMessageID()
+	if err != nil {
+		return err
+	}
 	if err := PublishGob(ch, exchange, key, routing.GameLog{
+		ID:          id,
 		CurrentTime: time.Now(),
 		Message:     message,
 		Username:    username,
-	}); err != nil {
+		GameID:      gameID,
+	}, opts...); err != nil {
 		err := fmt.Errorf("failed to publish gamelog: %w", err)
 		return err
 	}
 	return nil
 }
 
+func newMessageID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		err := fmt.Errorf("could not generate message ID: %w", err)
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
 func DeclareAndBind(conn *amqp.Connection, exchange, queueName, key string, queueType SimpleQueueType) (*amqp.Channel, amqp.Queue, error) {
 	ch, err := conn.Channel()
 	if err != nil {
@@ -116,7 +150,9 @@ const (
 	NackDiscard
 )
 
-func SubscribeJSON[T any](conn *amqp.Connection, exchange, queueName, key string, queueType SimpleQueueType, handler func(T) AckType) error {
+func SubscribeJSON[T any](conn *amqp.Connection, exchange, queueName, key string, queueType SimpleQueueType, handler func(T) AckType, opts ...SubscribeOption[T]) error {
+	config := newSubscribeConfig(opts)
+
 	ch, queue, err := DeclareAndBind(conn, exchange, queueName, key, queueType)
 	if err != nil {
 		err := fmt.Errorf("failed to declare and bind: %w", err)
@@ -136,10 +172,8 @@ func SubscribeJSON[T any](conn *amqp.Connection, exchange, queueName, key string
 
 	go func() {
 		for delivery := range deliveriesCh {
-			var v T
-			if err := json.Unmarshal(delivery.Body, &v); err != nil {
-				slog.Error("failed to unmarshal delivery body", "error", err)
-				delivery.Nack(false, false)
+			v, ok := config.accept(ch, delivery, decodeJSON[T])
+			if !ok {
 				continue
 			}
 
@@ -173,7 +207,9 @@ func SubscribeJSON[T any](conn *amqp.Connection, exchange, queueName, key string
 	return nil
 }
 
-func SubscribeGob[T any](conn *amqp.Connection, exchange, queueName, key string, queueType SimpleQueueType, handler func(T) AckType) error {
+func SubscribeGob[T any](conn *amqp.Connection, exchange, queueName, key string, queueType SimpleQueueType, handler func(T) AckType, opts ...SubscribeOption[T]) error {
+	config := newSubscribeConfig(opts)
+
 	ch, queue, err := DeclareAndBind(conn, exchange, queueName, key, queueType)
 	if err != nil {
 		err := fmt.Errorf("failed to declare and bind: %w", err)
@@ -188,11 +224,8 @@ func SubscribeGob[T any](conn *amqp.Connection, exchange, queueName, key string,
 
 	go func() {
 		for delivery := range deliveriesCh {
-			var v T
-			decoder := gob.NewDecoder(bytes.NewBuffer(delivery.Body))
-			if err := decoder.Decode(&v); err != nil {
-				slog.Error("failed to decode delivery body", "error", err)
-				delivery.Nack(false, false)
+			v, ok := config.accept(ch, delivery, decodeGob[T])
+			if !ok {
 				continue
 			}
 
@@ -222,3 +255,58 @@ func SubscribeGob[T any](conn *amqp.Connection, exchange, queueName, key string,
 
 	return nil
 }
+
+func decodeJSON[T any](body []byte, v *T) error {
+	return json.Unmarshal(body, v)
+}
+
+func decodeGob[T any](body []byte, v *T) error {
+	return gob.NewDecoder(bytes.NewBuffer(body)).Decode(v)
+}
+
+func (c subscribeConfig[T]) accept(ch *amqp.Channel, delivery amqp.Delivery, decode func([]byte, *T) error) (T, bool) {
+	var v T
+	signer, err := c.signer(delivery)
+	if err != nil {
+		slog.Error("rejected unverified delivery", "key", delivery.RoutingKey, "error", err)
+		delivery.Nack(false, false)
+		return v, false
+	}
+
+	body, err := c.decrypt(delivery)
+	if errors.Is(err, errNotForUs) {
+		slog.Error("dropped encrypted delivery without a decryption key", "key", delivery.RoutingKey)
+		delivery.Nack(false, false)
+		return v, false
+	}
+	if err != nil {
+		slog.Error("failed to decrypt delivery body", "key", delivery.RoutingKey, "error", err)
+		delivery.Nack(false, false)
+		return v, false
+	}
+
+	if err := decode(body, &v); err != nil {
+		slog.Error("failed to decode delivery body", "error", err)
+		delivery.Nack(false, false)
+		return v, false
+	}
+
+	if err := c.verify(delivery, signer, v); err != nil {
+		slog.Error("rejected spoofed delivery", "key", delivery.RoutingKey, "error", err)
+		delivery.Nack(false, false)
+		return v, false
+	}
+
+	if err := c.admission(delivery, v); err != nil {
+		slog.Info("rejected delivery", "key", delivery.RoutingKey, "reason", err)
+		if err := deadLetter(ch, delivery, err.Error()); err != nil {
+			slog.Error("failed to dead-letter message", "error", err)
+			delivery.Nack(false, false)
+			return v, false
+		}
+		delivery.Ack(false)
+		return v, false
+	}
+
+	return v, true
+}
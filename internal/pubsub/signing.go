@@ -0,0 +1,105 @@
+package pubsub
+
+import (
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"strings"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	HeaderSigner    = "x-peril-signer"
+	HeaderSignature = "x-peril-signature"
+)
+
+type PublishOption func(key string, msg *amqp.Publishing) error
+
+type Signer struct {
+	Username string
+	Key      ed25519.PrivateKey
+}
+
+func WithSigner(s Signer) PublishOption {
+	return func(key string, msg *amqp.Publishing) error {
+		if len(s.Key) != ed25519.PrivateKeySize {
+			return errors.New("signer has no private key")
+		}
+		if msg.Headers == nil {
+			msg.Headers = amqp.Table{}
+		}
+		msg.Headers[HeaderSigner] = s.Username
+		msg.Headers[HeaderSignature] = ed25519.Sign(s.Key, signedContent(key, msg.Body))
+		return nil
+	}
+}
+
+type KeyRing interface {
+	PublicKey(username string) (ed25519.PublicKey, bool)
+}
+
+type SubscribeOption[T any] func(*subscribeConfig[T])
+
+type subscribeConfig[T any] struct {
+	keys       KeyRing
+	claims     []func(key string, v T) string
+	decryptKey *ecdh.PrivateKey
+	admit      func(key string, v T) error
+}
+
+func WithVerifier[T any](keys KeyRing, claims ...func(key string, v T) string) SubscribeOption[T] {
+	return func(c *subscribeConfig[T]) {
+		c.keys = keys
+		c.claims = claims
+	}
+}
+
+func KeySuffix[T any](key string, _ T) string {
+	return key[strings.LastIndex(key, ".")+1:]
+}
+
+func newSubscribeConfig[T any](opts []SubscribeOption[T]) subscribeConfig[T] {
+	c := subscribeConfig[T]{}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+func (c subscribeConfig[T]) signer(delivery amqp.Delivery) (string, error) {
+	if c.keys == nil {
+		return "", nil
+	}
+
+	signer, _ := delivery.Headers[HeaderSigner].(string)
+	signature, _ := delivery.Headers[HeaderSignature].([]byte)
+	if signer == "" || len(signature) == 0 {
+		return "", errors.New("message is not signed")
+	}
+	public, ok := c.keys.PublicKey(signer)
+	if !ok {
+		return "", fmt.Errorf("no signing key known for %s", signer)
+	}
+	if !ed25519.Verify(public, signedContent(delivery.RoutingKey, delivery.Body), signature) {
+		return "", fmt.Errorf("invalid signature from %s", signer)
+	}
+	return signer, nil
+}
+
+func (c subscribeConfig[T]) verify(delivery amqp.Delivery, signer string, v T) error {
+	for _, claim := range c.claims {
+		if claimed := claim(delivery.RoutingKey, v); claimed != signer {
+			return fmt.Errorf("message signed by %s claims to be from %s", signer, claimed)
+		}
+	}
+	return nil
+}
+
+func signedContent(key string, body []byte) []byte {
+	content := make([]byte, 0, len(key)+1+len(body))
+	content = append(content, key...)
+	content = append(content, '\n')
+	return append(content, body...)
+}
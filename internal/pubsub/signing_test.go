@@ -0,0 +1,103 @@
+package pubsub
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+type staticKeys map[string]ed25519.PublicKey
+
+func (k staticKeys) PublicKey(username string) (ed25519.PublicKey, bool) {
+	key, ok := k[username]
+	return key, ok
+}
+
+type testMessage struct {
+	Username string
+}
+
+func testSender(_ string, msg testMessage) string {
+	return msg.Username
+}
+
+func newTestSigner(t *testing.T, username string) Signer {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Signer{Username: username, Key: private}
+}
+
+func TestVerifySignature(t *testing.T) {
+	alice := newTestSigner(t, "alice")
+	mallory := newTestSigner(t, "mallory")
+	keys := staticKeys{"alice": alice.Key.Public().(ed25519.PublicKey)}
+	const key = "game.presence.alice"
+
+	sign := func(s Signer, key string, body string) amqp.Delivery {
+		msg := amqp.Publishing{Body: []byte(body)}
+		if err := WithSigner(s)(key, &msg); err != nil {
+			t.Fatal(err)
+		}
+		return amqp.Delivery{RoutingKey: key, Body: msg.Body, Headers: msg.Headers}
+	}
+
+	tests := []struct {
+		name     string
+		delivery func() amqp.Delivery
+		claims   []func(string, testMessage) string
+		want     string
+		wantErr  bool
+	}{
+		{"valid signature", func() amqp.Delivery { return sign(alice, key, "hello") }, nil, "alice", false},
+		{"valid signature with matching claim", func() amqp.Delivery { return sign(alice, key, "hello") }, []func(string, testMessage) string{KeySuffix[testMessage]}, "alice", false},
+		{"unsigned", func() amqp.Delivery { return amqp.Delivery{RoutingKey: key, Body: []byte("hello")} }, nil, "", true},
+		{"tampered body", func() amqp.Delivery {
+			d := sign(alice, key, "hello")
+			d.Body = []byte("goodbye")
+			return d
+		}, nil, "", true},
+		{"replayed on another key", func() amqp.Delivery {
+			d := sign(alice, key, "hello")
+			d.RoutingKey = "game.presence.bob"
+			return d
+		}, nil, "", true},
+		{"unknown signer", func() amqp.Delivery { return sign(mallory, key, "hello") }, nil, "", true},
+		{"impersonated signer", func() amqp.Delivery {
+			d := sign(mallory, key, "hello")
+			d.Headers[HeaderSigner] = "alice"
+			return d
+		}, nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newSubscribeConfig([]SubscribeOption[testMessage]{WithVerifier(keys, tt.claims...)})
+			d := tt.delivery()
+			signer, err := c.signer(d)
+			if err == nil {
+				err = c.verify(d, signer, testMessage{Username: signer})
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if signer != tt.want {
+				t.Errorf("signer = %q, want %q", signer, tt.want)
+			}
+		})
+	}
+
+	t.Run("claims must match the signer", func(t *testing.T) {
+		c := newSubscribeConfig([]SubscribeOption[testMessage]{WithVerifier(keys, testSender)})
+		d := sign(alice, key, "hello")
+		signer, err := c.signer(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.verify(d, signer, testMessage{Username: "bob"}); err == nil {
+			t.Error("accepted a message signed by alice that claims to be from bob")
+		}
+	})
+}
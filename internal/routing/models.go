@@ -1,13 +1,197 @@
 package routing
 
-import "time"
+import (
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type PlayingState struct {
 	IsPaused bool
 }
 
 type GameLog struct {
+	ID          string
 	CurrentTime time.Time
 	Message     string
 	Username    string
+	GameID      string
+}
+
+type ScenarioRequest struct {
+	Username string
+}
+
+type ChatMessage struct {
+	GameID  string
+	Channel string
+	From    string
+	To      string
+	Message string
+	SentAt  time.Time
+}
+
+type LobbyAction string
+
+const (
+	LobbyList   LobbyAction = "list"
+	LobbyCreate LobbyAction = "create"
+	LobbyJoin   LobbyAction = "join"
+	LobbyMatch  LobbyAction = "match"
+)
+
+type LobbyRequest struct {
+	Username string
+	Token    string
+	Action   LobbyAction
+	GameID   string
+}
+
+type GameInfo struct {
+	ID        string
+	Players   []string
+	TurnBased bool
+	Scenario  string
+	Ended     bool
+}
+
+type GameOver struct {
+	GameID  string
+	Winner  string
+	Reason  string
+	Scores  map[string]int
+	EndedAt time.Time
+}
+
+type LobbyResponse struct {
+	Action LobbyAction
+	Games  []GameInfo
+	GameID string
+	Error  string
+}
+
+const HeartbeatInterval = 5 * time.Second
+
+type PresenceEvent string
+
+const (
+	PresenceJoin      PresenceEvent = "join"
+	PresenceHeartbeat PresenceEvent = "heartbeat"
+	PresenceLeave     PresenceEvent = "leave"
+)
+
+type Presence struct {
+	Username string
+	Token    string
+	GameID   string
+	Event    PresenceEvent
+	SentAt   time.Time
+}
+
+type PresenceEntry struct {
+	Username      string
+	Online        bool
+	LastSeen      time.Time
+	SigningKey    []byte
+	EncryptionKey []byte
+}
+
+type Roster struct {
+	GameID   string
+	Username string
+	Online   bool
+	Players  []PresenceEntry
+}
+
+type PlayerStats struct {
+	Username       string
+	Wins           int
+	Losses         int
+	Draws          int
+	UnitsDestroyed int
+	UnitsLost      int
+	Territories    int
+	Rating         int
+}
+
+type StatsRequest struct {
+	Username string
+	Target   string
+}
+
+type StatsResponse struct {
+	Stats       PlayerStats
+	Leaderboard []PlayerStats
+	Error       string
+}
+
+type ModerationAction string
+
+const (
+	ModerationWarn   ModerationAction = "warn"
+	ModerationMute   ModerationAction = "mute"
+	ModerationUnmute ModerationAction = "unmute"
+	ModerationKick   ModerationAction = "kick"
+	ModerationBan    ModerationAction = "ban"
+)
+
+type Moderation struct {
+	Username string
+	Action   ModerationAction
+	Reason   string
+	Until    time.Time
+}
+
+const (
+	AuthPasswordRequired = "password required"
+	ServerSigner         = "server"
+)
+
+type AuthHello struct {
+	ReplyTo string
+}
+
+type AuthChallenge struct {
+	Nonce         string
+	ServerKey     []byte
+	EncryptionKey []byte
+	Signature     []byte
+	Error         string
+}
+
+func (c AuthChallenge) SignedContent() []byte {
+	return []byte(strings.Join([]string{
+		"peril-challenge",
+		c.Nonce,
+		hex.EncodeToString(c.EncryptionKey),
+	}, "|"))
+}
+
+type AuthRequest struct {
+	Username      string
+	ReplyTo       string
+	Nonce         string
+	Password      string
+	Signature     []byte
+	SigningKey    []byte
+	EncryptionKey []byte
+	SentAt        time.Time
+}
+
+func (r AuthRequest) SignedContent() []byte {
+	return []byte(strings.Join([]string{
+		"peril-join",
+		r.Username,
+		r.Nonce,
+		strconv.FormatInt(r.SentAt.UnixNano(), 10),
+		r.ReplyTo,
+		hex.EncodeToString(r.SigningKey),
+		hex.EncodeToString(r.EncryptionKey),
+	}, "|"))
+}
+
+type AuthResponse struct {
+	Token string
+	Error string
 }
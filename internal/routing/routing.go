@@ -1,17 +1,65 @@
 package routing
 
+import "strings"
+
 const (
-	ArmyMovesPrefix = "army_moves"
+	ArmyMovesPrefix   = "army_moves"
+	ArmyIntelPrefix   = "army_intel"
+	PlayerStatePrefix = "player_state"
 
 	WarRecognitionsPrefix = "war"
+	WarDeclarationsPrefix = "war_declarations"
+	DeclaredWarKey        = "declared_war"
+	WarResultsPrefix      = "war_results"
+	BattleReportsPrefix   = "battle_reports"
+	ConfirmedBattlesKey   = "confirmed_battles"
+
+	DiplomacyPrefix = "diplomacy"
+
+	ChatPrefix   = "chat"
+	ChatGlobal   = "global"
+	ChatAlliance = "alliance"
+	ChatDirect   = "dm"
+
+	ChatLogsQueue = "chat_logs"
+
+	PresencePrefix = "presence"
+	RosterKey      = "roster"
 
 	PauseKey = "pause"
 
+	TurnKey      = "turn"
+	OrdersPrefix = "orders"
+
+	ScenarioKey        = "scenario"
+	ScenarioRequestKey = "scenario_request"
+	GameOverKey        = "game_over"
+
 	GameLogSlug = "game_logs"
 )
 
+const (
+	AuthKey          = "auth"
+	AuthChallengeKey = "auth_challenge"
+	LobbyKey         = "lobby"
+	ModerationKey    = "moderation"
+	StatsKey         = "stats"
+	DefaultGameID    = "default"
+)
+
 const (
 	ExchangePerilDirect = "peril_direct"
 	ExchangePerilTopic  = "peril_topic"
 	ExchangePerilDLX    = "peril_dlx"
 )
+
+func ChatKey(channel, to string) string {
+	if channel == ChatGlobal {
+		return ChatPrefix + "." + ChatGlobal
+	}
+	return ChatPrefix + "." + channel + "." + to
+}
+
+func GameKey(gameID string, parts ...string) string {
+	return strings.Join(append([]string{gameID}, parts...), ".")
+}
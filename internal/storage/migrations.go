@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+var migrations = []string{
+	`CREATE TABLE players (
+		username   TEXT PRIMARY KEY,
+		first_seen INTEGER NOT NULL,
+		last_seen  INTEGER NOT NULL
+	);
+	CREATE TABLE games (
+		id         TEXT PRIMARY KEY,
+		scenario   TEXT NOT NULL,
+		turn_based INTEGER NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+	CREATE TABLE game_players (
+		game_id   TEXT NOT NULL REFERENCES games(id),
+		username  TEXT NOT NULL REFERENCES players(username),
+		joined_at INTEGER NOT NULL,
+		PRIMARY KEY (game_id, username)
+	);
+	CREATE TABLE battles (
+		id              INTEGER PRIMARY KEY AUTOINCREMENT,
+		game_id         TEXT NOT NULL,
+		location        TEXT NOT NULL,
+		attacker        TEXT NOT NULL,
+		defender        TEXT NOT NULL,
+		winner          TEXT NOT NULL,
+		loser           TEXT NOT NULL,
+		attacker_power  INTEGER NOT NULL,
+		defender_power  INTEGER NOT NULL,
+		attacker_losses INTEGER NOT NULL,
+		defender_losses INTEGER NOT NULL,
+		detail          TEXT NOT NULL,
+		fought_at       INTEGER NOT NULL
+	);
+	CREATE INDEX battles_attacker ON battles(attacker);
+	CREATE INDEX battles_defender ON battles(defender);
+	CREATE TABLE logs (
+		id       INTEGER PRIMARY KEY AUTOINCREMENT,
+		time     INTEGER NOT NULL,
+		game_id  TEXT NOT NULL,
+		username TEXT NOT NULL,
+		message  TEXT NOT NULL
+	);
+	CREATE INDEX logs_username ON logs(username, time);`,
+	`CREATE TABLE ratings (
+		username   TEXT PRIMARY KEY,
+		rating     REAL NOT NULL,
+		battles    INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);`,
+	`ALTER TABLE games ADD COLUMN winner TEXT;
+	ALTER TABLE games ADD COLUMN end_reason TEXT;
+	ALTER TABLE games ADD COLUMN ended_at INTEGER;`,
+	`ALTER TABLE logs ADD COLUMN message_id TEXT;
+	CREATE UNIQUE INDEX logs_message_id ON logs(message_id);`,
+}
+
+func migrate(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		err := fmt.Errorf("could not create migrations table: %w", err)
+		return err
+	}
+
+	var current int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		err := fmt.Errorf("could not read schema version: %w", err)
+		return err
+	}
+
+	for i := current; i < len(migrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			err := fmt.Errorf("could not begin migration %d: %w", i+1, err)
+			return err
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			err := fmt.Errorf("could not apply migration %d: %w", i+1, err)
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, i+1); err != nil {
+			tx.Rollback()
+			err := fmt.Errorf("could not record migration %d: %w", i+1, err)
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			err := fmt.Errorf("could not commit migration %d: %w", i+1, err)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+)
+
+const (
+	InitialRating = 1500.0
+	eloK          = 32.0
+)
+
+func expectedScore(rating, opponent float64) float64 {
+	return 1 / (1 + math.Pow(10, (opponent-rating)/400))
+}
+
+func elo(rating, opponent, score float64) float64 {
+	return rating + eloK*(score-expectedScore(rating, opponent))
+}
+
+func (s *Store) Rating(username string) (float64, error) {
+	return rating(s.db, username)
+}
+
+func (s *Store) Ratings(usernames []string) (map[string]float64, error) {
+	ratings := map[string]float64{}
+	for _, username := range usernames {
+		r, err := s.Rating(username)
+		if err != nil {
+			return nil, err
+		}
+		ratings[username] = r
+	}
+	return ratings, nil
+}
+
+type querier interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func rating(q querier, username string) (float64, error) {
+	var r float64
+	err := q.QueryRow(`SELECT rating FROM ratings WHERE username = ?`, username).Scan(&r)
+	if errors.Is(err, sql.ErrNoRows) {
+		return InitialRating, nil
+	}
+	if err != nil {
+		err := fmt.Errorf("could not read rating for %s: %w", username, err)
+		return 0, err
+	}
+	return r, nil
+}
+
+func warSides(battles []gamelogic.BattleResult) (string, []string, float64) {
+	attacker := battles[0].Attacker
+	defenders := []string{}
+	attackerWins, defenderWins := 0, 0
+	for _, br := range battles {
+		for _, username := range append([]string{br.Defender}, br.Allies...) {
+			if username != attacker && !slices.Contains(defenders, username) {
+				defenders = append(defenders, username)
+			}
+		}
+		switch br.Winner {
+		case br.Attacker:
+			attackerWins++
+		case br.Defender:
+			defenderWins++
+		}
+	}
+	slices.Sort(defenders)
+
+	score := 0.5
+	switch {
+	case attackerWins > defenderWins:
+		score = 1
+	case defenderWins > attackerWins:
+		score = 0
+	}
+	return attacker, defenders, score
+}
+
+func updateRatings(tx *sql.Tx, battles []gamelogic.BattleResult, at time.Time) error {
+	attacker, defenders, score := warSides(battles)
+	if len(defenders) == 0 {
+		return nil
+	}
+
+	attackerRating, err := rating(tx, attacker)
+	if err != nil {
+		return err
+	}
+	defenderRatings := map[string]float64{}
+	defenseRating := 0.0
+	for _, username := range defenders {
+		r, err := rating(tx, username)
+		if err != nil {
+			return err
+		}
+		defenderRatings[username] = r
+		defenseRating += r / float64(len(defenders))
+	}
+
+	updated := map[string]float64{attacker: elo(attackerRating, defenseRating, score)}
+	for username, r := range defenderRatings {
+		updated[username] = elo(r, attackerRating, 1-score)
+	}
+	for username, r := range updated {
+		if _, err := tx.Exec(`
+			INSERT INTO ratings (username, rating, battles, updated_at) VALUES (?, ?, 1, ?)
+			ON CONFLICT (username) DO UPDATE SET
+				rating = excluded.rating,
+				battles = battles + 1,
+				updated_at = excluded.updated_at`,
+			username, r, at.UnixMilli()); err != nil {
+			err := fmt.Errorf("could not save rating for %s: %w", username, err)
+			return err
+		}
+	}
+	return nil
+}
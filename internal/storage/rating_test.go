@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+)
+
+func TestExpectedScore(t *testing.T) {
+	tests := []struct {
+		rating, opponent float64
+		want             float64
+	}{
+		{1500, 1500, 0.5},
+		{1900, 1500, 10.0 / 11},
+		{1500, 1900, 1.0 / 11},
+		{2300, 1500, 1.0 / (1 + math.Pow(10, -2))},
+	}
+	for _, tt := range tests {
+		if got := expectedScore(tt.rating, tt.opponent); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("expectedScore(%v, %v) = %v, want %v", tt.rating, tt.opponent, got, tt.want)
+		}
+	}
+}
+
+func TestElo(t *testing.T) {
+	tests := []struct {
+		name             string
+		rating, opponent float64
+		score            float64
+		want             float64
+	}{
+		{"even win", 1500, 1500, 1, 1516},
+		{"even loss", 1500, 1500, 0, 1484},
+		{"even draw", 1500, 1500, 0.5, 1500},
+		{"upset win", 1500, 1900, 1, 1500 + eloK*10/11},
+		{"expected win", 1900, 1500, 1, 1900 + eloK/11},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := elo(tt.rating, tt.opponent, tt.score); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("elo(%v, %v, %v) = %v, want %v", tt.rating, tt.opponent, tt.score, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWarSides(t *testing.T) {
+	battles := []gamelogic.BattleResult{
+		{Location: "asia", Attacker: "alice", Defender: "bob", Winner: "alice"},
+		{Location: "europe", Attacker: "alice", Defender: "bob", Allies: []string{"carol"}, Winner: "bob"},
+		{Location: "africa", Attacker: "alice", Defender: "bob", Allies: []string{"carol", "dave"}, Winner: "alice"},
+	}
+	attacker, defenders, score := warSides(battles)
+	if attacker != "alice" {
+		t.Errorf("attacker = %q, want alice", attacker)
+	}
+	if want := []string{"bob", "carol", "dave"}; !slices.Equal(defenders, want) {
+		t.Errorf("defenders = %v, want %v", defenders, want)
+	}
+	if score != 1 {
+		t.Errorf("score = %v, want 1", score)
+	}
+
+	_, _, score = warSides(battles[:2])
+	if score != 0.5 {
+		t.Errorf("drawn war score = %v, want 0.5", score)
+	}
+}
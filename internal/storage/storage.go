@@ -0,0 +1,341 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+	_ "modernc.org/sqlite"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+type Battle struct {
+	GameID         string
+	Location       string
+	Attacker       string
+	Defender       string
+	Winner         string
+	Loser          string
+	AttackerLosses int
+	DefenderLosses int
+	FoughtAt       time.Time
+}
+
+type GameRecord struct {
+	ID        string
+	Scenario  string
+	TurnBased bool
+	Players   int
+	CreatedAt time.Time
+}
+
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)")
+	if err != nil {
+		err := fmt.Errorf("could not open database: %w", err)
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func (s *Store) TouchPlayer(username string, at time.Time) error {
+	if _, err := s.db.Exec(`
+		INSERT INTO players (username, first_seen, last_seen) VALUES (?, ?, ?)
+		ON CONFLICT (username) DO UPDATE SET last_seen = excluded.last_seen`,
+		username, at.UnixMilli(), at.UnixMilli()); err != nil {
+		err := fmt.Errorf("could not save player %s: %w", username, err)
+		return err
+	}
+	return nil
+}
+
+func (s *Store) CreateGame(info routing.GameInfo, at time.Time) error {
+	if _, err := s.db.Exec(`
+		INSERT INTO games (id, scenario, turn_based, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (id) DO NOTHING`,
+		info.ID, info.Scenario, info.TurnBased, at.UnixMilli()); err != nil {
+		err := fmt.Errorf("could not save game %s: %w", info.ID, err)
+		return err
+	}
+	return nil
+}
+
+func (s *Store) JoinGame(gameID, username string, at time.Time) error {
+	if err := s.TouchPlayer(username, at); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(`
+		INSERT INTO game_players (game_id, username, joined_at) VALUES (?, ?, ?)
+		ON CONFLICT (game_id, username) DO NOTHING`,
+		gameID, username, at.UnixMilli()); err != nil {
+		err := fmt.Errorf("could not save %s joining %s: %w", username, gameID, err)
+		return err
+	}
+	return nil
+}
+
+func (s *Store) EndGame(over routing.GameOver) error {
+	if _, err := s.db.Exec(`
+		UPDATE games SET winner = ?, end_reason = ?, ended_at = ? WHERE id = ?`,
+		over.Winner, over.Reason, over.EndedAt.UnixMilli(), over.GameID); err != nil {
+		err := fmt.Errorf("could not save the end of game %s: %w", over.GameID, err)
+		return err
+	}
+	return nil
+}
+
+func (s *Store) RecordWar(gameID string, battles []gamelogic.BattleResult, at time.Time) error {
+	if len(battles) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		err := fmt.Errorf("could not begin war write: %w", err)
+		return err
+	}
+	for _, br := range battles {
+		if err := insertBattle(tx, gameID, br, at); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := updateRatings(tx, battles, at); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		err := fmt.Errorf("could not commit war: %w", err)
+		return err
+	}
+	return nil
+}
+
+func insertBattle(tx *sql.Tx, gameID string, br gamelogic.BattleResult, at time.Time) error {
+	detail, err := json.Marshal(br)
+	if err != nil {
+		err := fmt.Errorf("could not encode battle: %w", err)
+		return err
+	}
+
+	defenderLosses := 0
+	for username, casualties := range br.Casualties {
+		if username != br.Attacker {
+			defenderLosses += len(casualties)
+		}
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO battles (game_id, location, attacker, defender, winner, loser,
+			attacker_power, defender_power, attacker_losses, defender_losses, detail, fought_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		gameID, br.Location, br.Attacker, br.Defender, br.Winner, br.Loser,
+		br.AttackerPower, br.DefenderPower, len(br.Casualties[br.Attacker]), defenderLosses,
+		string(detail), at.UnixMilli()); err != nil {
+		err := fmt.Errorf("could not save battle: %w", err)
+		return err
+	}
+	return nil
+}
+
+func (s *Store) Battles(username string, limit int) ([]Battle, error) {
+	rows, err := s.db.Query(`
+		SELECT game_id, location, attacker, defender, winner, loser, attacker_losses, defender_losses, fought_at
+		FROM battles
+		WHERE ? = '' OR attacker = ? OR defender = ?
+		ORDER BY fought_at DESC, id DESC
+		LIMIT ?`, username, username, username, limit)
+	if err != nil {
+		err := fmt.Errorf("could not query battles: %w", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	battles := []Battle{}
+	for rows.Next() {
+		var b Battle
+		var foughtAt int64
+		if err := rows.Scan(&b.GameID, &b.Location, &b.Attacker, &b.Defender, &b.Winner, &b.Loser, &b.AttackerLosses, &b.DefenderLosses, &foughtAt); err != nil {
+			err := fmt.Errorf("could not read battle: %w", err)
+			return nil, err
+		}
+		b.FoughtAt = time.UnixMilli(foughtAt)
+		battles = append(battles, b)
+	}
+	return battles, rows.Err()
+}
+
+func (s *Store) Games(username string, limit int) ([]GameRecord, error) {
+	rows, err := s.db.Query(`
+		SELECT g.id, g.scenario, g.turn_based, g.created_at, COUNT(gp.username)
+		FROM games g
+		LEFT JOIN game_players gp ON gp.game_id = g.id
+		WHERE ? = '' OR g.id IN (SELECT game_id FROM game_players WHERE username = ?)
+		GROUP BY g.id
+		ORDER BY g.created_at DESC
+		LIMIT ?`, username, username, limit)
+	if err != nil {
+		err := fmt.Errorf("could not query games: %w", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	games := []GameRecord{}
+	for rows.Next() {
+		var g GameRecord
+		var createdAt int64
+		if err := rows.Scan(&g.ID, &g.Scenario, &g.TurnBased, &createdAt, &g.Players); err != nil {
+			err := fmt.Errorf("could not read game: %w", err)
+			return nil, err
+		}
+		g.CreatedAt = time.UnixMilli(createdAt)
+		games = append(games, g)
+	}
+	return games, rows.Err()
+}
+
+func (s *Store) Write(logs ...routing.GameLog) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		err := fmt.Errorf("could not begin log write: %w", err)
+		return err
+	}
+	stmt, err := tx.Prepare(`INSERT OR IGNORE INTO logs (time, game_id, username, message, message_id) VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		tx.Rollback()
+		err := fmt.Errorf("could not prepare log write: %w", err)
+		return err
+	}
+	defer stmt.Close()
+
+	for _, gl := range logs {
+		messageID := sql.NullString{String: gl.ID, Valid: gl.ID != ""}
+		if _, err := stmt.Exec(gl.CurrentTime.UnixMilli(), gl.GameID, gl.Username, gl.Message, messageID); err != nil {
+			tx.Rollback()
+			err := fmt.Errorf("could not write log: %w", err)
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		err := fmt.Errorf("could not commit logs: %w", err)
+		return err
+	}
+	return nil
+}
+
+func (s *Store) Logs(username, gameID string, limit int) ([]routing.GameLog, error) {
+	rows, err := s.db.Query(`
+		SELECT COALESCE(message_id, ''), time, game_id, username, message
+		FROM logs
+		WHERE (? = '' OR username = ?) AND (? = '' OR game_id = ?)
+		ORDER BY time DESC, id DESC
+		LIMIT ?`, username, username, gameID, gameID, limit)
+	if err != nil {
+		err := fmt.Errorf("could not query logs: %w", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs := []routing.GameLog{}
+	for rows.Next() {
+		var gl routing.GameLog
+		var at int64
+		if err := rows.Scan(&gl.ID, &at, &gl.GameID, &gl.Username, &gl.Message); err != nil {
+			err := fmt.Errorf("could not read log: %w", err)
+			return nil, err
+		}
+		gl.CurrentTime = time.UnixMilli(at)
+		logs = append(logs, gl)
+	}
+	return logs, rows.Err()
+}
+
+func (s *Store) Sync() error {
+	return nil
+}
+
+const statsQuery = `
+	SELECT s.username, s.wins, s.losses, s.draws, s.destroyed, s.lost, COALESCE(r.rating, ?)
+	FROM (
+		SELECT username, SUM(wins) AS wins, SUM(losses) AS losses, SUM(draws) AS draws,
+			SUM(destroyed) AS destroyed, SUM(lost) AS lost
+		FROM (
+			SELECT attacker AS username, winner = attacker AS wins, loser = attacker AS losses,
+				winner = '' AS draws, defender_losses AS destroyed, attacker_losses AS lost
+			FROM battles
+			UNION ALL
+			SELECT defender, winner = defender, loser = defender,
+				winner = '', attacker_losses, defender_losses
+			FROM battles
+		)
+		GROUP BY username
+	) s
+	LEFT JOIN ratings r ON r.username = s.username
+`
+
+func (s *Store) Leaderboard(limit int) ([]routing.PlayerStats, error) {
+	rows, err := s.db.Query(statsQuery+`
+		ORDER BY 7 DESC, s.wins DESC, s.username ASC
+		LIMIT ?`, InitialRating, limit)
+	if err != nil {
+		err := fmt.Errorf("could not query leaderboard: %w", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	leaderboard := []routing.PlayerStats{}
+	for rows.Next() {
+		stats, err := scanStats(rows)
+		if err != nil {
+			return nil, err
+		}
+		leaderboard = append(leaderboard, stats)
+	}
+	return leaderboard, rows.Err()
+}
+
+func (s *Store) PlayerStats(username string) (routing.PlayerStats, error) {
+	rows, err := s.db.Query(statsQuery+`
+		WHERE s.username = ?`, InitialRating, username)
+	if err != nil {
+		err := fmt.Errorf("could not query stats for %s: %w", username, err)
+		return routing.PlayerStats{}, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return routing.PlayerStats{}, err
+		}
+		rating, err := s.Rating(username)
+		return routing.PlayerStats{Username: username, Rating: int(math.Round(rating))}, err
+	}
+	return scanStats(rows)
+}
+
+func scanStats(rows *sql.Rows) (routing.PlayerStats, error) {
+	var stats routing.PlayerStats
+	var rating float64
+	if err := rows.Scan(&stats.Username, &stats.Wins, &stats.Losses, &stats.Draws, &stats.UnitsDestroyed, &stats.UnitsLost, &rating); err != nil {
+		err := fmt.Errorf("could not read stats: %w", err)
+		return routing.PlayerStats{}, err
+	}
+	stats.Rating = int(math.Round(rating))
+	return stats, nil
+}
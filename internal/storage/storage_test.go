@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+func TestWriteLogs(t *testing.T) {
+	at := time.UnixMilli(1_700_000_000_000)
+	line := func(id string) routing.GameLog {
+		return routing.GameLog{ID: id, CurrentTime: at, GameID: "default", Username: "alice", Message: "attacked"}
+	}
+
+	tests := []struct {
+		name    string
+		batches [][]routing.GameLog
+		want    int
+	}{
+		{"redelivered message is stored once", [][]routing.GameLog{{line("a")}, {line("a")}}, 1},
+		{"duplicate within a batch is stored once", [][]routing.GameLog{{line("a"), line("a")}}, 1},
+		{"repeated lines with different IDs are kept", [][]routing.GameLog{{line("a"), line("b")}}, 2},
+		{"repeated lines without IDs are kept", [][]routing.GameLog{{line(""), line("")}, {line("")}}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := Open(filepath.Join(t.TempDir(), "peril.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer store.Close()
+
+			for _, batch := range tt.batches {
+				if err := store.Write(batch...); err != nil {
+					t.Fatal(err)
+				}
+			}
+			logs, err := store.Logs("", "", 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(logs) != tt.want {
+				t.Errorf("stored %d logs, want %d", len(logs), tt.want)
+			}
+		})
+	}
+}